@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	apiKeySeparator   = "."
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+	apiKeySaltBytes   = 16
+)
+
+var ErrMalformedApiKey = errors.New("malformed API key")
+
+// GenerateApiKey returns a new key in the form prefix.secret, along with the salt and the hash to store for it.
+// The key itself is never stored, it can only be shown once to its creator.
+func GenerateApiKey() (key, prefix string, salt, hash []byte, err error) {
+	prefixBytes, err := randomBytes(apiKeyPrefixBytes)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	secretBytes, err := randomBytes(apiKeySecretBytes)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	salt, err = randomBytes(apiKeySaltBytes)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+	return prefix + apiKeySeparator + secret, prefix, salt, HashApiKeySecret(salt, secret), nil
+}
+
+// SplitApiKey returns the public prefix identifying the key and its secret part.
+func SplitApiKey(key string) (prefix, secret string, err error) {
+	parts := strings.SplitN(key, apiKeySeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", ErrMalformedApiKey
+	}
+	return parts[0], parts[1], nil
+}
+
+func HashApiKeySecret(salt []byte, secret string) []byte {
+	digest := sha256.New()
+	digest.Write(salt)
+	digest.Write([]byte(secret))
+	return digest.Sum(nil)
+}
+
+// VerifyApiKeySecret reports whether the secret matches the stored salted hash, in constant time.
+func VerifyApiKeySecret(salt, hash []byte, secret string) bool {
+	return subtle.ConstantTimeCompare(HashApiKeySecret(salt, secret), hash) == 1
+}
+
+func randomBytes(size int) ([]byte, error) {
+	buffer := make([]byte, size)
+	_, err := rand.Read(buffer)
+	return buffer, err
+}
@@ -0,0 +1,45 @@
+// +build !integration
+
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+)
+
+func TestGenerateApiKey_Unit_Success(t *testing.T) {
+	key, prefix, salt, hash, err := auth.GenerateApiKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key, prefix+"."))
+	assert.NotContains(t, string(hash), key)
+
+	keyPrefix, secret, splitErr := auth.SplitApiKey(key)
+	require.NoError(t, splitErr)
+	assert.Equal(t, prefix, keyPrefix)
+	assert.True(t, auth.VerifyApiKeySecret(salt, hash, secret))
+	assert.False(t, auth.VerifyApiKeySecret(salt, hash, secret+"x"))
+}
+
+func TestGenerateApiKey_Unit_Unique(t *testing.T) {
+	key1, prefix1, salt1, _, err1 := auth.GenerateApiKey()
+	key2, prefix2, salt2, _, err2 := auth.GenerateApiKey()
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+
+	assert.NotEqual(t, key1, key2)
+	assert.NotEqual(t, prefix1, prefix2)
+	assert.NotEqual(t, salt1, salt2)
+}
+
+func TestSplitApiKey_Unit_Fail(t *testing.T) {
+	for _, key := range []string{"", "nosecret", ".secret", "prefix."} {
+		_, _, err := auth.SplitApiKey(key)
+		assert.Equal(t, auth.ErrMalformedApiKey, err, key)
+	}
+}
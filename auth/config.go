@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	jwksFileEnvVar            = "AUTH_JWKS_FILE"
+	jwksUrlEnvVar             = "AUTH_JWKS_URL"
+	jwksRefreshIntervalEnvVar = "AUTH_JWKS_REFRESH_INTERVAL" // duration, e.g. 10m
+	hs256SecretEnvVar         = "AUTH_HS256_SECRET"          // development only
+	issuerEnvVar              = "AUTH_ISSUER"
+	audienceEnvVar            = "AUTH_AUDIENCE"
+
+	jwksFileDefault            = ""
+	jwksUrlDefault             = ""
+	jwksRefreshIntervalDefault = 10 * time.Minute
+	hs256SecretDefault         = ""
+	issuerDefault              = ""
+	audienceDefault            = ""
+)
+
+func loadConfig() *config {
+	logging.Log.Debug("Load authentication configurations")
+	return &config{
+		jwksFile:            utils.GetStringEnv(jwksFileEnvVar, jwksFileDefault),
+		jwksUrl:             utils.GetStringEnv(jwksUrlEnvVar, jwksUrlDefault),
+		jwksRefreshInterval: utils.GetDurationEnv(jwksRefreshIntervalEnvVar, jwksRefreshIntervalDefault),
+		hs256Secret:         utils.GetStringEnv(hs256SecretEnvVar, hs256SecretDefault),
+		issuer:              utils.GetStringEnv(issuerEnvVar, issuerDefault),
+		audience:            utils.GetStringEnv(audienceEnvVar, audienceDefault),
+	}
+}
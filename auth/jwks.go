@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	// minRefreshInterval bounds how often unknown key IDs can trigger a refresh, e.g. with forged tokens
+	minRefreshInterval = 30 * time.Second
+	jwksFetchTimeout   = 10 * time.Second
+)
+
+func newKeySet(source func() ([]byte, error), refreshInterval time.Duration) *keySet {
+	return &keySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]crypto.PublicKey),
+	}
+}
+
+// key returns the public key with the given ID, reloading the key set when it is stale or the ID is unknown,
+// so that rotated keys are picked up without restarting.
+func (k *keySet) key(kid string) (crypto.PublicKey, error) {
+	k.mutex.RLock()
+	key, found := k.keys[kid]
+	age := time.Since(k.refreshedAt)
+	k.mutex.RUnlock()
+
+	if (found && age < k.refreshInterval) || (!found && age < minRefreshInterval) {
+		if !found {
+			return nil, fmt.Errorf("key %q not found", kid)
+		}
+		return key, nil
+	}
+
+	refreshErr := k.refresh()
+	if refreshErr != nil {
+		if found {
+			// keep serving the cached key while the source is unavailable
+			logging.SugaredLog.Warnf("JWKS refresh failed, using cached keys: %s", refreshErr.Error())
+			return key, nil
+		}
+		return nil, refreshErr
+	}
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	key, found = k.keys[kid]
+	if !found {
+		return nil, fmt.Errorf("key %q not found", kid)
+	}
+	return key, nil
+}
+
+func (k *keySet) refresh() error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	// set before loading, so that a failing source is not hammered either
+	k.refreshedAt = time.Now()
+
+	document, sourceErr := k.source()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	keys, parseErr := parseJwks(document)
+	if parseErr != nil {
+		return parseErr
+	}
+	k.keys = keys
+	logging.SugaredLog.Debugf("JWKS refreshed, %d keys loaded", len(keys))
+	return nil
+}
+
+// parseJwks returns the RSA and EC signature keys of the document, skipping keys of other types or uses.
+func parseJwks(document []byte) (map[string]crypto.PublicKey, error) {
+	var set jwks
+	unmarshErr := json.Unmarshal(document, &set)
+	if unmarshErr != nil {
+		return nil, fmt.Errorf("JWKS not valid: %s", unmarshErr.Error())
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var keyErr error
+		switch jwk.Kty {
+		case "RSA":
+			key, keyErr = jwk.rsaPublicKey()
+		case "EC":
+			key, keyErr = jwk.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if keyErr != nil {
+			return nil, fmt.Errorf("JWKS key %q not valid: %s", jwk.Kid, keyErr.Error())
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (j *jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, nErr := decodeBigInt(j.N)
+	if nErr != nil {
+		return nil, nErr
+	}
+	e, eErr := decodeBigInt(j.E)
+	if eErr != nil {
+		return nil, eErr
+	}
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("exponent too large")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (j *jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("curve %q not supported", j.Crv)
+	}
+	x, xErr := decodeBigInt(j.X)
+	if xErr != nil {
+		return nil, xErr
+	}
+	y, yErr := decodeBigInt(j.Y)
+	if yErr != nil {
+		return nil, yErr
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point not on curve %s", j.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+func fileSource(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return ioutil.ReadFile(path)
+	}
+}
+
+func urlSource(url string) func() ([]byte, error) {
+	client := &http.Client{Timeout: jwksFetchTimeout}
+	return func() ([]byte, error) {
+		response, getErr := client.Get(url)
+		if getErr != nil {
+			return nil, getErr
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("JWKS fetch from %s failed: status %d", url, response.StatusCode)
+		}
+		return ioutil.ReadAll(response.Body)
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type config struct {
+	jwksFile            string
+	jwksUrl             string
+	jwksRefreshInterval time.Duration
+	hs256Secret         string
+	issuer              string
+	audience            string
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	// Tenant is empty if the token does not bind the caller to a tenant
+	Tenant string
+	// Roles are evaluated by the policy engine, on top of the roles it binds to scopes and subjects
+	Roles []string
+}
+
+type Verifier struct {
+	config *config
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+// keySet caches the public keys of a JWKS document by key ID.
+type keySet struct {
+	source          func() ([]byte, error)
+	refreshInterval time.Duration
+
+	mutex       sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	refreshedAt time.Time
+}
+
+type jwks struct {
+	Keys []*jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	// Scope is the space separated list of RFC 8693, Scp the array some providers use instead
+	Scope  string   `json:"scope,omitempty"`
+	Scp    []string `json:"scp,omitempty"`
+	Tenant string   `json:"tenant_id,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+var ErrNoKeys = errors.New("no JWKS nor HS256 secret configured")
+
+func NewVerifier() (*Verifier, error) {
+	logging.Log.Info("Create new token verifier")
+
+	return newVerifier(loadConfig())
+}
+
+func newVerifier(cfg *config) (*Verifier, error) {
+	verifier := &Verifier{
+		config: cfg,
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "ES256", "HS256"})),
+	}
+
+	switch {
+	case cfg.jwksFile != "" && cfg.jwksUrl != "":
+		return nil, fmt.Errorf("JWKS file and URL are mutually exclusive")
+	case cfg.jwksFile != "":
+		verifier.keys = newKeySet(fileSource(cfg.jwksFile), cfg.jwksRefreshInterval)
+	case cfg.jwksUrl != "":
+		verifier.keys = newKeySet(urlSource(cfg.jwksUrl), cfg.jwksRefreshInterval)
+	case cfg.hs256Secret == "":
+		logging.SugaredLog.Warnf("Token verification disabled: %s, only anonymous requests are accepted", ErrNoKeys)
+		return verifier, nil
+	}
+
+	if verifier.keys != nil {
+		// fail fast on a broken key set, later refresh failures keep the cached keys
+		refreshErr := verifier.keys.refresh()
+		if refreshErr != nil {
+			return nil, refreshErr
+		}
+	}
+	if cfg.hs256Secret != "" {
+		logging.Log.Warn("HS256 tokens accepted, do not use in production")
+	}
+	return verifier, nil
+}
+
+// Verify checks signature, expiry, issuer and audience of the bearer token and returns its principal.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	var tokenClaims claims
+	_, parseErr := v.parser.ParseWithClaims(token, &tokenClaims, v.keyFunc)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if tokenClaims.Subject == "" {
+		return nil, fmt.Errorf("token subject missing")
+	}
+	if tokenClaims.ExpiresAt == nil {
+		return nil, fmt.Errorf("token expiry missing")
+	}
+	if v.config.issuer != "" && !tokenClaims.VerifyIssuer(v.config.issuer, true) {
+		return nil, fmt.Errorf("token issuer not valid")
+	}
+	if v.config.audience != "" && !tokenClaims.VerifyAudience(v.config.audience, true) {
+		return nil, fmt.Errorf("token audience not valid")
+	}
+
+	scopes := strings.Fields(tokenClaims.Scope)
+	scopes = append(scopes, tokenClaims.Scp...)
+	return &Principal{
+		Subject: tokenClaims.Subject,
+		Scopes:  scopes,
+		Tenant:  tokenClaims.Tenant,
+		Roles:   tokenClaims.Roles,
+	}, nil
+}
+
+// keyFunc returns the key to check the token signature with, making sure it matches the token algorithm
+// so that e.g. a public RSA key can never be used as an HMAC secret.
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, isHmac := token.Method.(*jwt.SigningMethodHMAC); isHmac {
+		if v.config.hs256Secret == "" {
+			return nil, fmt.Errorf("HS256 tokens not accepted")
+		}
+		return []byte(v.config.hs256Secret), nil
+	}
+
+	if v.keys == nil {
+		return nil, ErrNoKeys
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, keyErr := v.keys.key(kid)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, isRsa := token.Method.(*jwt.SigningMethodRSA); isRsa {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if _, isEcdsa := token.Method.(*jwt.SigningMethodECDSA); isEcdsa {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q does not match algorithm %s", kid, token.Method.Alg())
+}
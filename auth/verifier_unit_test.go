@@ -0,0 +1,164 @@
+// +build !integration
+
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	jwksFileKey    = "AUTH_JWKS_FILE"
+	hs256SecretKey = "AUTH_HS256_SECRET"
+	issuerKey      = "AUTH_ISSUER"
+
+	hs256Secret = "dev-secret"
+	issuer      = "https://issuer.example.com"
+	subject     = "user-1"
+	tenant      = "acme"
+	rsaKeyId    = "rsa-1"
+	ecKeyId     = "ec-1"
+)
+
+func newClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       subject,
+		"iss":       issuer,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"scope":     "products:read products:write",
+		"tenant_id": tenant,
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims, key interface{}) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func encode(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+// writeJwks writes a JWKS document with the public keys to a temporary file and points the configuration to it
+func writeJwks(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) {
+	document, marshalErr := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": rsaKeyId, "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": ecKeyId, "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		},
+	})
+	require.NoError(t, marshalErr)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, ioutil.WriteFile(path, document, 0600))
+	require.NoError(t, os.Setenv(jwksFileKey, path))
+}
+
+func TestVerify_Unit_Success_HS256(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	require.NoError(t, os.Setenv(hs256SecretKey, hs256Secret))
+	defer os.Unsetenv(hs256SecretKey)
+
+	verifier, verifierErr := auth.NewVerifier()
+	require.NoError(t, verifierErr)
+
+	principal, err := verifier.Verify(sign(t, jwt.SigningMethodHS256, "", newClaims(), []byte(hs256Secret)))
+
+	require.NoError(t, err)
+	assert.Equal(t, subject, principal.Subject)
+	assert.Equal(t, []string{"products:read", "products:write"}, principal.Scopes)
+	assert.Equal(t, tenant, principal.Tenant)
+}
+
+func TestVerify_Unit_Success_JWKS(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	rsaKey, rsaErr := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, rsaErr)
+	ecKey, ecErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, ecErr)
+	writeJwks(t, rsaKey, ecKey)
+	defer os.Unsetenv(jwksFileKey)
+	require.NoError(t, os.Setenv(issuerKey, issuer))
+	defer os.Unsetenv(issuerKey)
+
+	verifier, verifierErr := auth.NewVerifier()
+	require.NoError(t, verifierErr)
+
+	rsaPrincipal, rsaVerifyErr := verifier.Verify(sign(t, jwt.SigningMethodRS256, rsaKeyId, newClaims(), rsaKey))
+	require.NoError(t, rsaVerifyErr)
+	assert.Equal(t, subject, rsaPrincipal.Subject)
+
+	claims := newClaims()
+	delete(claims, "scope")
+	claims["scp"] = []string{"products:admin"}
+	ecPrincipal, ecVerifyErr := verifier.Verify(sign(t, jwt.SigningMethodES256, ecKeyId, claims, ecKey))
+	require.NoError(t, ecVerifyErr)
+	assert.Equal(t, []string{"products:admin"}, ecPrincipal.Scopes)
+}
+
+func TestVerify_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	rsaKey, rsaErr := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, rsaErr)
+	ecKey, ecErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, ecErr)
+	writeJwks(t, rsaKey, ecKey)
+	defer os.Unsetenv(jwksFileKey)
+	require.NoError(t, os.Setenv(issuerKey, issuer))
+	defer os.Unsetenv(issuerKey)
+
+	verifier, verifierErr := auth.NewVerifier()
+	require.NoError(t, verifierErr)
+
+	expired := newClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	noExpiry := newClaims()
+	delete(noExpiry, "exp")
+	otherIssuer := newClaims()
+	otherIssuer["iss"] = "https://evil.example.com"
+	otherKey, otherErr := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, otherErr)
+
+	tokens := map[string]string{
+		"expired":        sign(t, jwt.SigningMethodRS256, rsaKeyId, expired, rsaKey),
+		"no-expiry":      sign(t, jwt.SigningMethodRS256, rsaKeyId, noExpiry, rsaKey),
+		"other-issuer":   sign(t, jwt.SigningMethodRS256, rsaKeyId, otherIssuer, rsaKey),
+		"wrong-key":      sign(t, jwt.SigningMethodRS256, rsaKeyId, newClaims(), otherKey),
+		"unknown-kid":    sign(t, jwt.SigningMethodRS256, "unknown", newClaims(), rsaKey),
+		"kid-alg-swap":   sign(t, jwt.SigningMethodES256, rsaKeyId, newClaims(), ecKey),
+		"hs256-disabled": sign(t, jwt.SigningMethodHS256, "", newClaims(), []byte(hs256Secret)),
+		"garbage":        "not.a.token",
+	}
+	for name, token := range tokens {
+		_, err := verifier.Verify(token)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,27 @@
+package commons
+
+import "runtime"
+
+// Build information, injected at build time by the Makefile with
+// -ldflags "-X github.com/bygui86/go-postgres-cicd/commons.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+	BuildTime string `json:"build_time"`
+}
+
+func GetBuildInfo() *BuildInfo {
+	return &BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		BuildTime: BuildTime,
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (k *ApiKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("name missing")
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("scopes missing")
+	}
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+// IsActive reports whether the key is neither revoked nor expired at the given time.
+func (k *ApiKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// IsUsageStale reports whether last_used_at is worth updating at the given time, see TouchApiKey.
+func (k *ApiKey) IsUsageStale(now time.Time) bool {
+	return k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyUsageResolution
+}
+
+func GetApiKeys(db Querier, ctx context.Context) ([]*ApiKey, error) {
+	span := startSpan(ctx, "get-api-keys-db")
+	defer span.Finish()
+
+	span.SetTag("query", getApiKeysQuery)
+
+	rows, queryErr := db.QueryContext(ctx, getApiKeysQuery)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	apiKeys := make([]*ApiKey, 0)
+	for rows.Next() {
+		var apiKey ApiKey
+		rowErr := rows.Scan(&apiKey.ID, &apiKey.Name, &apiKey.Prefix, pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt,
+			&apiKey.LastUsedAt, &apiKey.RevokedAt, &apiKey.CreatedAt)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+
+	span.SetTag("api-keys-found", len(apiKeys))
+	span.LogKV("api-keys-found", len(apiKeys))
+
+	return apiKeys, nil
+}
+
+// GetApiKeyByPrefix returns the key with the given prefix whatever its tenant, it is meant to run outside of tenant
+// transactions as keys are looked up to find the tenant of a request.
+// It returns sql.ErrNoRows if no key has the prefix.
+func GetApiKeyByPrefix(db Querier, prefix string, ctx context.Context) (*ApiKey, error) {
+	span := startSpan(ctx, "get-api-key-by-prefix-db")
+	defer span.Finish()
+
+	span.SetTag("api-key-prefix", prefix)
+	span.LogKV("api-key-prefix", prefix)
+
+	apiKey := &ApiKey{Prefix: prefix}
+	err := db.QueryRowContext(ctx, getApiKeyByPrefixQuery, prefix).
+		Scan(&apiKey.ID, &apiKey.TenantID, &apiKey.Name, &apiKey.Salt, &apiKey.Hash, pq.Array(&apiKey.Scopes),
+			&apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.RevokedAt, &apiKey.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func CreateApiKey(db Querier, apiKey *ApiKey, ctx context.Context) error {
+	span := startSpan(ctx, "create-api-key-db")
+	defer span.Finish()
+
+	span.SetTag("api-key", apiKey.String())
+	span.LogKV("api-key", apiKey.String())
+
+	return db.QueryRowContext(ctx, createApiKeyQuery,
+		apiKey.Name, apiKey.Prefix, apiKey.Salt, apiKey.Hash, pq.Array(apiKey.Scopes), apiKey.ExpiresAt).
+		Scan(&apiKey.ID, &apiKey.CreatedAt)
+}
+
+// RevokeApiKey returns sql.ErrNoRows if the key does not exist.
+func RevokeApiKey(db Querier, apiKey *ApiKey, ctx context.Context) error {
+	span := startSpan(ctx, "revoke-api-key-db")
+	defer span.Finish()
+
+	span.SetTag("api-key-id", apiKey.ID)
+	span.LogKV("api-key-id", apiKey.ID)
+
+	return db.QueryRowContext(ctx, revokeApiKeyQuery, apiKey.ID).
+		Scan(&apiKey.RevokedAt)
+}
+
+// TouchApiKey records that the key was just used.
+func TouchApiKey(db Querier, apiKeyId int, ctx context.Context) error {
+	span := startSpan(ctx, "touch-api-key-db")
+	defer span.Finish()
+
+	span.SetTag("api-key-id", apiKeyId)
+	span.LogKV("api-key-id", apiKeyId)
+
+	_, err := db.ExecContext(ctx, touchApiKeyQuery, apiKeyId)
+	return err
+}
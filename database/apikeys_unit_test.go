@@ -0,0 +1,156 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	apiKeyId     = 3
+	apiKeyName   = "erp-sync"
+	apiKeyPrefix = "0a1b2c3d4e5f"
+)
+
+func TestApiKeyValidate_Unit_Fail(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	assert.Error(t, (&database.ApiKey{Scopes: []string{"products:read"}}).Validate())
+	assert.Error(t, (&database.ApiKey{Name: apiKeyName}).Validate())
+	assert.Error(t, (&database.ApiKey{Name: apiKeyName, Scopes: []string{"products:read"}, ExpiresAt: &past}).Validate())
+}
+
+func TestApiKeyIsActive_Unit(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.True(t, (&database.ApiKey{}).IsActive(now))
+	assert.True(t, (&database.ApiKey{ExpiresAt: &future}).IsActive(now))
+	assert.False(t, (&database.ApiKey{ExpiresAt: &past}).IsActive(now))
+	assert.False(t, (&database.ApiKey{RevokedAt: &past}).IsActive(now))
+}
+
+func TestApiKeyIsUsageStale_Unit(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-10 * time.Second)
+	old := now.Add(-2 * time.Minute)
+
+	assert.True(t, (&database.ApiKey{}).IsUsageStale(now))
+	assert.False(t, (&database.ApiKey{LastUsedAt: &recent}).IsUsageStale(now))
+	assert.True(t, (&database.ApiKey{LastUsedAt: &old}).IsUsageStale(now))
+}
+
+func TestGetApiKeyByPrefix_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "tenant_id", "name", "salt", "hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at"}).
+		AddRow(apiKeyId, "acme", apiKeyName, []byte("salt"), []byte("hash"), "{products:read,products:write}", nil, nil, nil, now)
+
+	mock.ExpectQuery(getApiKeyByPrefixQuery).
+		WithArgs(apiKeyPrefix).
+		WillReturnRows(rows)
+
+	apiKey, err := database.GetApiKeyByPrefix(db, apiKeyPrefix, context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, apiKeyId, apiKey.ID)
+	assert.Equal(t, "acme", apiKey.TenantID)
+	assert.Equal(t, []byte("hash"), apiKey.Hash)
+	assert.Equal(t, []string{"products:read", "products:write"}, apiKey.Scopes)
+	assert.True(t, apiKey.IsActive(now))
+}
+
+func TestGetApiKeyByPrefix_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(getApiKeyByPrefixQuery).
+		WithArgs(apiKeyPrefix).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := database.GetApiKeyByPrefix(db, apiKeyPrefix, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestCreateApiKey_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "created_at"}).
+		AddRow(apiKeyId, now)
+
+	mock.ExpectQuery(createApiKeyQuery).
+		WithArgs(apiKeyName, apiKeyPrefix, []byte("salt"), []byte("hash"), "{\"products:read\"}", nil).
+		WillReturnRows(rows)
+
+	apiKey := &database.ApiKey{Name: apiKeyName, Prefix: apiKeyPrefix, Salt: []byte("salt"), Hash: []byte("hash"),
+		Scopes: []string{"products:read"}}
+	err := database.CreateApiKey(db, apiKey, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, apiKeyId, apiKey.ID)
+	assert.Equal(t, now, apiKey.CreatedAt)
+}
+
+func TestRevokeApiKey_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"revoked_at"}).
+		AddRow(now)
+
+	mock.ExpectQuery(revokeApiKeyQuery).
+		WithArgs(apiKeyId).
+		WillReturnRows(rows)
+
+	apiKey := &database.ApiKey{ID: apiKeyId}
+	err := database.RevokeApiKey(db, apiKey, context.Background())
+
+	assert.NoError(t, err)
+	require.NotNil(t, apiKey.RevokedAt)
+	assert.False(t, apiKey.IsActive(now))
+}
+
+func TestTouchApiKey_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(touchApiKeyQuery).
+		WithArgs(apiKeyId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := database.TouchApiKey(db, apiKeyId, context.Background())
+
+	assert.NoError(t, err)
+}
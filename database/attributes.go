@@ -0,0 +1,125 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	emptyAttributes = "{}"
+	schemaRefKey    = "$ref"
+)
+
+func attributesOrEmpty(attributes json.RawMessage) string {
+	if len(attributes) == 0 {
+		return emptyAttributes
+	}
+	return string(attributes)
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// attributeCandidates returns the JSON documents an attribute filter value can match.
+// Query parameters are always strings, so a value like "10" or "true" also matches the typed JSON number or boolean.
+func attributeCandidates(key, value string) []string {
+	asString, _ := json.Marshal(map[string]string{key: value})
+	candidates := []string{string(asString)}
+
+	var typed interface{}
+	if json.Unmarshal([]byte(value), &typed) == nil {
+		switch typed.(type) {
+		case float64, bool:
+			asTyped, _ := json.Marshal(map[string]interface{}{key: typed})
+			candidates = append(candidates, string(asTyped))
+		}
+	}
+	return candidates
+}
+
+// ValidateSchema checks that the given document is a valid JSON Schema referring only to its own definitions.
+func ValidateSchema(schema json.RawMessage) error {
+	refErr := checkLocalRefs(schema)
+	if refErr != nil {
+		return refErr
+	}
+	_, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+	return err
+}
+
+// checkLocalRefs rejects the $refs outside of the schema document, e.g. URLs or files, that gojsonschema would load
+// while compiling it.
+func checkLocalRefs(schema json.RawMessage) error {
+	var document interface{}
+	err := json.Unmarshal(schema, &document)
+	if err != nil {
+		return err
+	}
+	return walkRefs(document)
+}
+
+func walkRefs(node interface{}) error {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		for key, value := range typed {
+			if ref, isString := value.(string); key == schemaRefKey && isString && !strings.HasPrefix(ref, "#") {
+				return fmt.Errorf("$ref %q not allowed, only references within the schema starting with # are", ref)
+			}
+			err := walkRefs(value)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range typed {
+			err := walkRefs(item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateAttributes checks that attributes are a JSON object and, if a category schema is given, that they satisfy it.
+func ValidateAttributes(schema, attributes json.RawMessage) error {
+	var object map[string]interface{}
+	unmarshErr := json.Unmarshal([]byte(attributesOrEmpty(attributes)), &object)
+	if unmarshErr != nil || object == nil {
+		return fmt.Errorf("attributes must be a JSON object")
+	}
+	if len(schema) == 0 {
+		return nil
+	}
+	// schemas stored before references were checked may still point outside
+	refErr := checkLocalRefs(schema)
+	if refErr != nil {
+		return refErr
+	}
+
+	result, validateErr := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(schema),
+		gojsonschema.NewStringLoader(attributesOrEmpty(attributes)),
+	)
+	if validateErr != nil {
+		return validateErr
+	}
+	if !result.Valid() {
+		details := make([]string, 0, len(result.Errors()))
+		for _, resultErr := range result.Errors() {
+			details = append(details, resultErr.String())
+		}
+		return fmt.Errorf("attributes not valid: %s", strings.Join(details, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// +build !integration
+
+package database_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+const categorySchema = `{
+	"type": "object",
+	"properties": {
+		"color": {"type": "string", "enum": ["red", "green", "blue"]},
+		"size": {"type": "integer", "minimum": 1}
+	},
+	"required": ["color"]
+}`
+
+func TestValidateSchema_Unit_Success(t *testing.T) {
+	err := database.ValidateSchema(json.RawMessage(categorySchema))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateSchema_Unit_Fail(t *testing.T) {
+	err := database.ValidateSchema(json.RawMessage(`{"type": 42}`))
+
+	assert.Error(t, err)
+}
+
+func TestValidateSchema_Unit_Success_LocalRef(t *testing.T) {
+	err := database.ValidateSchema(json.RawMessage(`{
+		"definitions": {"color": {"type": "string"}},
+		"properties": {"color": {"$ref": "#/definitions/color"}}
+	}`))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateSchema_Unit_Fail_RemoteRef(t *testing.T) {
+	err := database.ValidateSchema(json.RawMessage(`{
+		"properties": {"color": {"allOf": [{"$ref": "http://169.254.169.254/latest/meta-data"}]}}
+	}`))
+
+	assert.Error(t, err)
+}
+
+func TestValidateAttributes_Unit_Fail_RemoteRef(t *testing.T) {
+	err := database.ValidateAttributes(json.RawMessage(`{"$ref": "file:///etc/passwd"}`), json.RawMessage(`{}`))
+
+	assert.Error(t, err)
+}
+
+func TestValidateAttributes_Unit_Success(t *testing.T) {
+	err := database.ValidateAttributes(json.RawMessage(categorySchema), json.RawMessage(productAttributes))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateAttributes_Unit_Success_NoSchema(t *testing.T) {
+	err := database.ValidateAttributes(nil, json.RawMessage(`{"anything": true}`))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateAttributes_Unit_Success_Empty(t *testing.T) {
+	err := database.ValidateAttributes(nil, nil)
+
+	assert.NoError(t, err)
+}
+
+func TestValidateAttributes_Unit_Fail_NotObject(t *testing.T) {
+	err := database.ValidateAttributes(nil, json.RawMessage(`["red"]`))
+
+	assert.Error(t, err)
+}
+
+func TestValidateAttributes_Unit_Fail_Schema(t *testing.T) {
+	err := database.ValidateAttributes(json.RawMessage(categorySchema), json.RawMessage(`{"color": "pink", "size": 0}`))
+
+	assert.Error(t, err)
+}
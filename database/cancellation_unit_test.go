@@ -0,0 +1,83 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	slowQueryDelay  = 5 * time.Second
+	queryDeadline   = 50 * time.Millisecond
+	cancelTolerance = time.Second
+)
+
+func TestGetProducts_Unit_Cancelled_Deadline(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, productAttributes, "0.00", 0)
+
+	mock.ExpectQuery(getProductsQuery).
+		WillDelayFor(slowQueryDelay).
+		WillReturnRows(rows)
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryDeadline)
+	defer cancel()
+
+	start := time.Now()
+	_, err := database.GetProducts(db, 0, 10, nil, ctx)
+
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(cancelTolerance), "slow query not cancelled")
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
+
+func TestDeleteProduct_Unit_Cancelled_Client(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deleteProductQuery).
+		WithArgs(productId).
+		WillDelayFor(slowQueryDelay).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(queryDeadline)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := database.DeleteProduct(db, productId, ctx)
+
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(cancelTolerance), "slow query not cancelled")
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestIsCancelled_Unit(t *testing.T) {
+	assert.True(t, database.IsCancelled(context.Canceled))
+	assert.True(t, database.IsCancelled(fmt.Errorf("get products: %w", context.DeadlineExceeded)))
+	assert.True(t, database.IsCancelled(&pq.Error{Code: "57014"}))
+	assert.False(t, database.IsCancelled(&pq.Error{Code: "23505"}))
+	assert.False(t, database.IsCancelled(fmt.Errorf("connection refused")))
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// GetCatalogueStats sums up the products, of all tenants if not run in a tenant transaction. The products are
+// counted by price band, the bounds being sorted ascending.
+func GetCatalogueStats(db Querier, priceBounds []float64, ctx context.Context) (*CatalogueStats, error) {
+	span := startSpan(ctx, "get-catalogue-stats-db")
+	defer span.Finish()
+
+	stats := &CatalogueStats{PriceBands: make([]int, len(priceBounds)+1)}
+	statsErr := db.QueryRowContext(ctx, getCatalogueStatsQuery).
+		Scan(&stats.Products, &stats.AveragePrice, &stats.MedianPrice)
+	if statsErr != nil {
+		return nil, statsErr
+	}
+
+	rows, bandsErr := db.QueryContext(ctx, getPriceBandsQuery, pq.Array(priceBounds))
+	if bandsErr != nil {
+		return nil, bandsErr
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var band, count int
+		rowErr := rows.Scan(&band, &count)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		if band >= 0 && band < len(stats.PriceBands) {
+			stats.PriceBands[band] = count
+		}
+	}
+	rowsErr := rows.Err()
+	if rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	span.SetTag("products", stats.Products)
+	span.LogKV("products", stats.Products)
+
+	return stats, nil
+}
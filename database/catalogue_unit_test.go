@@ -0,0 +1,55 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestGetCatalogueStats_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(getCatalogueStatsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "avg", "median"}).AddRow(6, 42.5, 30.0))
+	mock.ExpectQuery(getPriceBandsQuery).
+		WithArgs("{10,100}").
+		WillReturnRows(sqlmock.NewRows([]string{"band", "count"}).AddRow(0, 1).AddRow(2, 5))
+
+	stats, err := database.GetCatalogueStats(db, []float64{10, 100}, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, stats.Products)
+	assert.Equal(t, 42.5, stats.AveragePrice)
+	assert.Equal(t, 30.0, stats.MedianPrice)
+	assert.Equal(t, []int{1, 0, 5}, stats.PriceBands)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCatalogueStats_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(getCatalogueStatsQuery).
+		WillReturnError(context.DeadlineExceeded)
+
+	stats, err := database.GetCatalogueStats(db, []float64{10, 100}, context.Background())
+
+	assert.Error(t, err)
+	assert.Nil(t, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+)
+
+func GetCategorySchemas(db Querier, ctx context.Context) ([]*CategorySchema, error) {
+	span := startSpan(ctx, "get-category-schemas-db")
+	defer span.Finish()
+
+	span.SetTag("query", getCategorySchemasQuery)
+
+	rows, queryErr := db.QueryContext(ctx, getCategorySchemasQuery)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	schemas := make([]*CategorySchema, 0)
+	for rows.Next() {
+		var schema CategorySchema
+		var document []byte
+		rowErr := rows.Scan(&schema.Category, &document)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		schema.Schema = document
+		schemas = append(schemas, &schema)
+	}
+
+	span.SetTag("schemas-found", len(schemas))
+	span.LogKV("schemas-found", len(schemas))
+
+	return schemas, nil
+}
+
+// GetCategorySchema returns the JSON Schema of the category, or sql.ErrNoRows if the category has none.
+func GetCategorySchema(db Querier, category string, ctx context.Context) (json.RawMessage, error) {
+	span := startSpan(ctx, "get-category-schema-db")
+	defer span.Finish()
+
+	span.SetTag("category", category)
+	span.LogKV("category", category)
+
+	var schema []byte
+	err := db.QueryRowContext(ctx, getCategorySchemaQuery, category).Scan(&schema)
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func UpsertCategorySchema(db Querier, schema *CategorySchema, ctx context.Context) error {
+	span := startSpan(ctx, "upsert-category-schema-db")
+	defer span.Finish()
+
+	span.SetTag("category", schema.Category)
+	span.LogKV("category", schema.Category)
+
+	_, err := db.ExecContext(ctx, upsertCategorySchemaQuery, schema.Category, string(schema.Schema))
+	return err
+}
+
+func DeleteCategorySchema(db Querier, category string, ctx context.Context) error {
+	span := startSpan(ctx, "delete-category-schema-db")
+	defer span.Finish()
+
+	span.SetTag("category", category)
+	span.LogKV("category", category)
+
+	_, err := db.ExecContext(ctx, deleteCategorySchemaQuery, category)
+	return err
+}
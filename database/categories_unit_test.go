@@ -0,0 +1,71 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestGetCategorySchema_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"schema"}).
+		AddRow([]byte(categorySchema))
+
+	mock.ExpectQuery(getCategorySchemaQuery).
+		WithArgs(productCategory).
+		WillReturnRows(rows)
+
+	schema, err := database.GetCategorySchema(db, productCategory, context.Background())
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, categorySchema, string(schema))
+}
+
+func TestGetCategorySchema_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(getCategorySchemaQuery).
+		WithArgs(productCategory).
+		WillReturnError(sql.ErrNoRows)
+
+	schema, err := database.GetCategorySchema(db, productCategory, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Nil(t, schema)
+}
+
+func TestUpsertCategorySchema_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(upsertCategorySchemaQuery).
+		WithArgs(productCategory, categorySchema).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	schema := &database.CategorySchema{Category: productCategory, Schema: json.RawMessage(categorySchema)}
+	err := database.UpsertCategorySchema(db, schema, context.Background())
+
+	assert.NoError(t, err)
+}
@@ -4,6 +4,7 @@ package database_test
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -11,14 +12,138 @@ import (
 )
 
 const (
-	createTableQuery   = "CREATE TABLE IF NOT EXISTS products"
-	getProductsQuery   = "SELECT id,name,price FROM products"
-	getProductQuery    = "SELECT name,price FROM products"
-	createProductQuery = "INSERT INTO products"
-	updateProductQuery = "UPDATE products"
-	deleteProductQuery = "DELETE FROM products"
+	createTableQuery       = "CREATE TABLE IF NOT EXISTS products"
+	addStatusColumnsQuery  = "ALTER TABLE products"
+	createStatusIndexQuery = "CREATE INDEX IF NOT EXISTS products_status_idx"
+	getProductsQuery       = "SELECT id,name,price,status,publish_at,unpublish_at,category,attributes,rating_average,rating_count FROM products"
+	getProductQuery        = "SELECT name,price,status,publish_at,unpublish_at,category,attributes,rating_average,rating_count FROM products"
+	createProductQuery     = "INSERT INTO products"
+	updateProductQuery     = "UPDATE products"
+	deleteProductQuery     = "DELETE FROM products"
+	publishProductsQuery   = "UPDATE products SET status = 'published'"
+	archiveProductsQuery   = "UPDATE products SET status = 'archived'"
+
+	addAttributesColumnsQuery  = "ALTER TABLE products"
+	createAttributesIndexQuery = "CREATE INDEX IF NOT EXISTS products_attributes_idx"
+	createCategorySchemasQuery = "CREATE TABLE IF NOT EXISTS category_schemas"
+	getCategorySchemaQuery     = "SELECT schema FROM category_schemas"
+	upsertCategorySchemaQuery  = "INSERT INTO category_schemas"
+
+	createVariantsTableQuery = "CREATE TABLE IF NOT EXISTS product_variants"
+	createVariantsIndexQuery = "CREATE INDEX IF NOT EXISTS product_variants_product_idx"
+	getVariantsQuery         = "SELECT id,sku,name,price,stock,attributes FROM product_variants"
+	createVariantQuery       = "INSERT INTO product_variants"
+	updateVariantQuery       = "UPDATE product_variants"
+	deleteVariantQuery       = "DELETE FROM product_variants"
+
+	createTranslationsTableQuery       = "CREATE TABLE IF NOT EXISTS product_translations"
+	createTranslationsSearchIndexQuery = "CREATE INDEX IF NOT EXISTS product_translations_search_idx"
+	createProductsSearchIndexQuery     = "CREATE INDEX IF NOT EXISTS products_search_idx"
+	getProductsTranslationsQuery       = "SELECT product_id,locale,name,description FROM product_translations"
+	upsertTranslationQuery             = "INSERT INTO product_translations"
+	deleteTranslationQuery             = "DELETE FROM product_translations"
+
+	createImagesTableQuery        = "CREATE TABLE IF NOT EXISTS product_images"
+	createImagesIndexQuery        = "CREATE INDEX IF NOT EXISTS product_images_product_idx"
+	createImagesPrimaryIndexQuery = "CREATE UNIQUE INDEX IF NOT EXISTS product_images_primary_idx"
+	getImagesQuery                = "SELECT id,storage_key,file_name,content_type,size,checksum,position,is_primary,created_at"
+	createImageQuery              = "INSERT INTO product_images"
+	clearPrimaryImageQuery        = "UPDATE product_images SET is_primary = false"
+	updateImageQuery              = "UPDATE product_images SET position"
+	deleteImageQuery              = "DELETE FROM product_images"
+
+	createPromotionsTableQuery = "CREATE TABLE IF NOT EXISTS promotions"
+	getActivePromotionsQuery   = "SELECT id,name,kind,value,product_ids,categories,starts_at,ends_at,priority,stackable"
+	createPromotionQuery       = "INSERT INTO promotions"
+	updatePromotionQuery       = "UPDATE promotions"
+	deletePromotionQuery       = "DELETE FROM promotions"
+
+	createReviewsTableQuery   = "CREATE TABLE IF NOT EXISTS product_reviews"
+	createReviewsIndexQuery   = "CREATE INDEX IF NOT EXISTS product_reviews_product_idx"
+	addRatingColumnsQuery     = "ALTER TABLE products"
+	createRatingIndexQuery    = "CREATE INDEX IF NOT EXISTS products_rating_idx"
+	createRatingFunctionQuery = "CREATE OR REPLACE FUNCTION update_product_rating"
+	dropRatingTriggerQuery    = "DROP TRIGGER IF EXISTS product_reviews_rating_trigger"
+	createRatingTriggerQuery  = "CREATE TRIGGER product_reviews_rating_trigger"
+	getReviewsQuery           = "SELECT id,author,rating,title,body,status,created_at,moderated_at FROM product_reviews"
+	createReviewQuery         = "INSERT INTO product_reviews"
+	moderateReviewQuery       = "UPDATE product_reviews SET status"
+
+	createApiKeysTableQuery = "CREATE TABLE IF NOT EXISTS api_keys"
+	getApiKeyByPrefixQuery  = "SELECT id,tenant_id,name,salt,hash,scopes,expires_at,last_used_at,revoked_at,created_at FROM api_keys"
+	createApiKeyQuery       = "INSERT INTO api_keys"
+	revokeApiKeyQuery       = "UPDATE api_keys SET revoked_at"
+	touchApiKeyQuery        = "UPDATE api_keys SET last_used_at"
+
+	countTablesQuery = "SELECT count\\(\\*\\) FROM information_schema.tables"
+
+	getCatalogueStatsQuery = "SELECT count\\(\\*\\), COALESCE\\(avg\\(price\\), 0\\)"
+	getPriceBandsQuery     = "SELECT width_bucket\\(price"
+
+	createTenantRoleQuery     = "CREATE ROLE catalogue_tenant NOLOGIN"
+	grantTenantRoleQuery      = "GRANT catalogue_tenant TO CURRENT_USER"
+	grantTenantTablesQuery    = "GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public"
+	grantTenantSequencesQuery = "GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public"
+	setTenantRoleQuery        = "SET LOCAL ROLE catalogue_tenant"
+	setTenantQuery            = "SELECT set_config\\('app.tenant_id', \\$1, true\\)"
+)
+
+var (
+	tenantTables        = []string{"products", "category_schemas", "product_variants", "product_translations", "product_images", "promotions", "product_reviews", "api_keys"}
+	tenantProductTables = []string{"product_variants", "product_translations", "product_images", "product_reviews"}
 )
 
+// tenancyQueries lists the statements expected from InitDb to isolate tenants, in order
+func tenancyQueries() []string {
+	queries := []string{createTenantRoleQuery, grantTenantRoleQuery}
+	for _, table := range tenantTables {
+		queries = append(queries,
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant_id", table),
+			fmt.Sprintf("ALTER TABLE %s ALTER COLUMN tenant_id", table),
+		)
+	}
+	queries = append(queries, "products_tenant_id_key")
+	for _, table := range tenantProductTables {
+		queries = append(queries, table+"_tenant_product_fkey")
+	}
+	queries = append(queries, "category_schemas_tenant_pkey", "product_variants_tenant_sku_key")
+	for _, table := range tenantTables {
+		queries = append(queries,
+			fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+			fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s", table),
+			fmt.Sprintf("CREATE POLICY tenant_isolation ON %s", table),
+		)
+	}
+	return append(queries, grantTenantTablesQuery, grantTenantSequencesQuery)
+}
+
+// initDbQueries lists the statements expected from InitDb, in order
+var initDbQueries = append([]string{
+	createTableQuery,
+	addStatusColumnsQuery,
+	createStatusIndexQuery,
+	addAttributesColumnsQuery,
+	createAttributesIndexQuery,
+	createCategorySchemasQuery,
+	createVariantsTableQuery,
+	createVariantsIndexQuery,
+	createTranslationsTableQuery,
+	createTranslationsSearchIndexQuery,
+	createProductsSearchIndexQuery,
+	createImagesTableQuery,
+	createImagesIndexQuery,
+	createImagesPrimaryIndexQuery,
+	createPromotionsTableQuery,
+	createReviewsTableQuery,
+	createReviewsIndexQuery,
+	addRatingColumnsQuery,
+	createRatingIndexQuery,
+	createRatingFunctionQuery,
+	dropRatingTriggerQuery,
+	createRatingTriggerQuery,
+	createApiKeysTableQuery,
+}, tenancyQueries()...)
+
 /*
 	By default, sqlmock is preserving backward compatibility and default query matcher is sqlmock.QueryMatcherRegexp
 	which uses expected SQL string as a regular expression to match incoming query string.
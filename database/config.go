@@ -22,7 +22,7 @@ const (
 )
 
 func LoadConfig() *config {
-	logging.Log.Debug("Load REST configurations")
+	logging.Named(logging.DatabaseLogger).Debug("Load REST configurations")
 
 	return &config{
 		dbHost:     utils.GetStringEnv(dbHostEnvVar, dbHostDefault),
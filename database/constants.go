@@ -1,5 +1,38 @@
 package database
 
+import "time"
+
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusScheduled = "scheduled"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// ProductsSortRating orders products by average rating, then by number of ratings.
+const ProductsSortRating = "rating"
+
+const (
+	// DefaultTenant owns the rows that existed before multi-tenancy, and the rows written outside a tenant transaction
+	DefaultTenant = "default"
+	// tenantRole is the role tenant transactions switch to, row level security policies only apply to it
+	tenantRole = "catalogue_tenant"
+)
+
+// apiKeyUsageResolution is how stale last_used_at of API keys can be, so that keys aren't written on every request
+const apiKeyUsageResolution = time.Minute
+
+const (
+	PromotionKindPercentage = "percentage"
+	PromotionKindFixed      = "fixed"
+)
+
 const (
 	createTableQuery = `CREATE TABLE IF NOT EXISTS products(
 	id SERIAL,
@@ -7,11 +40,310 @@ const (
 	price NUMERIC(10,2) NOT NULL DEFAULT 0.00,
 	CONSTRAINT products_pkey PRIMARY KEY (id)
 )`
+	// existing rows were visible before lifecycle states existed, so they default to published
+	addStatusColumnsQuery = `ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published',
+	ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ`
+	createStatusIndexQuery    = "CREATE INDEX IF NOT EXISTS products_status_idx ON products (status)"
+	addAttributesColumnsQuery = `ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'`
+	// jsonb_path_ops only supports containment (@>), which is what attribute filters use, at a smaller index size
+	createAttributesIndexQuery      = "CREATE INDEX IF NOT EXISTS products_attributes_idx ON products USING GIN (attributes jsonb_path_ops)"
+	createCategorySchemasTableQuery = `CREATE TABLE IF NOT EXISTS category_schemas(
+	category TEXT NOT NULL,
+	schema JSONB NOT NULL,
+	CONSTRAINT category_schemas_pkey PRIMARY KEY (category)
+)`
 
-	getProductsQuery    = "SELECT id,name,price FROM products ORDER BY id ASC LIMIT $1 OFFSET $2"
-	getProductQuery     = "SELECT name,price FROM products WHERE id = $1"
-	createProductQuery  = "INSERT INTO products(name, price) VALUES($1, $2) RETURNING id"
-	updateProductQuery  = "UPDATE products SET name = $1, price = $2 WHERE id = $3"
-	deleteProductQuery  = "DELETE FROM products WHERE id = $1"
-	deleteProductsQuery = "DELETE FROM products"
+	createVariantsTableQuery = `CREATE TABLE IF NOT EXISTS product_variants(
+	id SERIAL,
+	product_id INTEGER NOT NULL,
+	sku TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	price NUMERIC(10,2),
+	stock INTEGER NOT NULL DEFAULT 0,
+	attributes JSONB NOT NULL DEFAULT '{}',
+	CONSTRAINT product_variants_pkey PRIMARY KEY (id),
+	CONSTRAINT product_variants_product_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+	CONSTRAINT product_variants_sku_key UNIQUE (sku),
+	CONSTRAINT product_variants_stock_check CHECK (stock >= 0)
+)`
+	createVariantsIndexQuery = "CREATE INDEX IF NOT EXISTS product_variants_product_idx ON product_variants (product_id)"
+	// search_config keeps the full-text search configuration matching the translation language, so a single
+	// expression index serves every language
+	createTranslationsTableQuery = `CREATE TABLE IF NOT EXISTS product_translations(
+	product_id INTEGER NOT NULL,
+	locale TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	search_config REGCONFIG NOT NULL DEFAULT 'simple',
+	CONSTRAINT product_translations_pkey PRIMARY KEY (product_id, locale),
+	CONSTRAINT product_translations_product_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE
+)`
+	createTranslationsSearchIndexQuery = `CREATE INDEX IF NOT EXISTS product_translations_search_idx ON product_translations
+	USING GIN (to_tsvector(search_config, name || ' ' || description))`
+	createProductsSearchIndexQuery = "CREATE INDEX IF NOT EXISTS products_search_idx ON products USING GIN (to_tsvector('simple', name))"
+	// image content lives in the blob store under storage_key, only its metadata is kept here
+	createImagesTableQuery = `CREATE TABLE IF NOT EXISTS product_images(
+	id SERIAL,
+	product_id INTEGER NOT NULL,
+	storage_key TEXT NOT NULL,
+	file_name TEXT NOT NULL DEFAULT '',
+	content_type TEXT NOT NULL,
+	size BIGINT NOT NULL,
+	checksum TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0,
+	is_primary BOOLEAN NOT NULL DEFAULT false,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	CONSTRAINT product_images_pkey PRIMARY KEY (id),
+	CONSTRAINT product_images_product_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+	CONSTRAINT product_images_storage_key_key UNIQUE (storage_key)
+)`
+	createImagesIndexQuery = "CREATE INDEX IF NOT EXISTS product_images_product_idx ON product_images (product_id, position)"
+	// at most one primary image per product
+	createImagesPrimaryIndexQuery = "CREATE UNIQUE INDEX IF NOT EXISTS product_images_primary_idx ON product_images (product_id) WHERE is_primary"
+	// a promotion targets products by ID and/or by category, product_ids are not foreign keys so that deleted
+	// products simply stop matching
+	createPromotionsTableQuery = `CREATE TABLE IF NOT EXISTS promotions(
+	id SERIAL,
+	name TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	value NUMERIC(10,2) NOT NULL,
+	product_ids INTEGER[] NOT NULL DEFAULT '{}',
+	categories TEXT[] NOT NULL DEFAULT '{}',
+	starts_at TIMESTAMPTZ,
+	ends_at TIMESTAMPTZ,
+	priority INTEGER NOT NULL DEFAULT 0,
+	stackable BOOLEAN NOT NULL DEFAULT false,
+	CONSTRAINT promotions_pkey PRIMARY KEY (id),
+	CONSTRAINT promotions_kind_check CHECK (kind IN ('percentage', 'fixed')),
+	CONSTRAINT promotions_value_check CHECK (value > 0),
+	CONSTRAINT promotions_window_check CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+)`
+	createReviewsTableQuery = `CREATE TABLE IF NOT EXISTS product_reviews(
+	id SERIAL,
+	product_id INTEGER NOT NULL,
+	author TEXT NOT NULL,
+	rating SMALLINT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	body TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	moderated_at TIMESTAMPTZ,
+	CONSTRAINT product_reviews_pkey PRIMARY KEY (id),
+	CONSTRAINT product_reviews_product_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+	CONSTRAINT product_reviews_rating_check CHECK (rating BETWEEN 1 AND 5),
+	CONSTRAINT product_reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected'))
+)`
+	createReviewsIndexQuery = "CREATE INDEX IF NOT EXISTS product_reviews_product_idx ON product_reviews (product_id, status)"
+	// only approved reviews count towards the rating kept on products, which lets GET /products sort on it
+	addRatingColumnsQuery = `ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3,2) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0`
+	createRatingIndexQuery = "CREATE INDEX IF NOT EXISTS products_rating_idx ON products (rating_average DESC, rating_count DESC, id)"
+	// the trigger recomputes the rating from scratch rather than incrementally. The product row is locked first:
+	// under READ COMMITTED concurrent moderations would otherwise each recompute it from a snapshot missing the
+	// other's change, while once locked the queries that follow see the reviews committed by the previous holder.
+	createRatingFunctionQuery = `CREATE OR REPLACE FUNCTION update_product_rating() RETURNS TRIGGER AS $$
+DECLARE
+	target INTEGER;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		target := OLD.product_id;
+	ELSE
+		target := NEW.product_id;
+	END IF;
+	PERFORM 1 FROM products WHERE id = target FOR UPDATE;
+	UPDATE products SET
+		rating_average = COALESCE((SELECT ROUND(AVG(rating), 2) FROM product_reviews WHERE product_id = target AND status = 'approved'), 0),
+		rating_count = (SELECT COUNT(*) FROM product_reviews WHERE product_id = target AND status = 'approved')
+	WHERE id = target;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`
+	// CREATE TRIGGER has no IF NOT EXISTS before PostgreSQL 14
+	dropRatingTriggerQuery   = "DROP TRIGGER IF EXISTS product_reviews_rating_trigger ON product_reviews"
+	createRatingTriggerQuery = `CREATE TRIGGER product_reviews_rating_trigger
+	AFTER INSERT OR UPDATE OF rating, status OR DELETE ON product_reviews
+	FOR EACH ROW EXECUTE PROCEDURE update_product_rating()`
+
+	// API keys are looked up by their public prefix before the tenant is known, so the prefix is unique across tenants.
+	// Only a salted hash of the secret part is stored.
+	createApiKeysTableQuery = `CREATE TABLE IF NOT EXISTS api_keys(
+	id SERIAL,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	salt BYTEA NOT NULL,
+	hash BYTEA NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	CONSTRAINT api_keys_pkey PRIMARY KEY (id),
+	CONSTRAINT api_keys_prefix_key UNIQUE (prefix)
+)`
+
+	createTenantRoleQuery = `DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '` + tenantRole + `') THEN
+		CREATE ROLE ` + tenantRole + ` NOLOGIN;
+	END IF;
+END $$`
+	grantTenantRoleQuery      = "GRANT " + tenantRole + " TO CURRENT_USER"
+	grantTenantTablesQuery    = "GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO " + tenantRole
+	grantTenantSequencesQuery = "GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO " + tenantRole
+	// existing rows belong to the default tenant, new rows to the tenant of the transaction writing them
+	addTenantColumnQueryFormat        = "ALTER TABLE %s ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '" + DefaultTenant + "'"
+	setTenantColumnDefaultQueryFormat = `ALTER TABLE %s ALTER COLUMN tenant_id
+	SET DEFAULT COALESCE(NULLIF(current_setting('app.tenant_id', true), ''), '` + DefaultTenant + `')`
+	// replaceConstraintQueryFormat swaps a constraint for its tenant aware version, only once
+	replaceConstraintQueryFormat = `DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '%[3]s') THEN
+		ALTER TABLE %[1]s DROP CONSTRAINT IF EXISTS %[2]s;
+		ALTER TABLE %[1]s ADD CONSTRAINT %[3]s %[4]s;
+	END IF;
+END $$`
+	// foreign keys are checked without row level security, so they include the tenant to prevent a tenant from
+	// attaching rows to the products of another one
+	createProductsTenantKeyQuery = `DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'products_tenant_id_key') THEN
+		ALTER TABLE products ADD CONSTRAINT products_tenant_id_key UNIQUE (tenant_id, id);
+	END IF;
+END $$`
+	tenantProductForeignKey     = "FOREIGN KEY (tenant_id, product_id) REFERENCES products (tenant_id, id) ON DELETE CASCADE"
+	enableRowLevelSecurityQuery = "ALTER TABLE %s ENABLE ROW LEVEL SECURITY"
+	dropTenantPolicyQuery       = "DROP POLICY IF EXISTS tenant_isolation ON %s"
+	// table owners bypass the policy, which is what migrations and background jobs spanning all tenants rely on
+	createTenantPolicyQuery = `CREATE POLICY tenant_isolation ON %s TO ` + tenantRole + `
+	USING (tenant_id = current_setting('app.tenant_id', true))
+	WITH CHECK (tenant_id = current_setting('app.tenant_id', true))`
+
+	// set_config with is_local true is the parameterizable form of SET LOCAL
+	setTenantQuery     = "SELECT set_config('app.tenant_id', $1, true)"
+	setTenantRoleQuery = "SET LOCAL ROLE " + tenantRole
+
+	getProductsQuery           = "SELECT id,name,price,status,publish_at,unpublish_at,category,attributes,rating_average,rating_count FROM products"
+	getProductsSearchCondition = `(to_tsvector('simple', name) @@ plainto_tsquery('simple', $%[1]d)
+	OR EXISTS (SELECT 1 FROM product_translations t WHERE t.product_id = products.id AND t.locale = ANY($%[2]d)
+	AND to_tsvector(t.search_config, t.name || ' ' || t.description) @@ plainto_tsquery(t.search_config, $%[1]d)))`
+	getProductsOrderBy       = " ORDER BY id ASC"
+	getProductsOrderByRating = " ORDER BY rating_average DESC, rating_count DESC, id ASC"
+	getProductsLimit         = " LIMIT $%d OFFSET $%d"
+	getProductQuery          = "SELECT name,price,status,publish_at,unpublish_at,category,attributes,rating_average,rating_count FROM products WHERE id = $1"
+	// getProductForUpdateQuery locks the row until the end of the transaction, see GetProductForUpdate
+	getProductForUpdateQuery = getProductQuery + " FOR UPDATE"
+	createProductQuery       = "INSERT INTO products(name, price, status, publish_at, unpublish_at, category, attributes) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id"
+	updateProductQuery       = "UPDATE products SET name = $1, price = $2, status = $3, publish_at = $4, unpublish_at = $5, category = $6, attributes = $7 WHERE id = $8"
+	deleteProductQuery       = "DELETE FROM products WHERE id = $1"
+	deleteProductsQuery      = "DELETE FROM products"
+
+	publishScheduledProductsQuery = `UPDATE products SET status = 'published'
+	WHERE status = 'scheduled' AND publish_at <= now() AND (unpublish_at IS NULL OR unpublish_at > now())`
+	archiveExpiredProductsQuery = `UPDATE products SET status = 'archived'
+	WHERE status IN ('scheduled', 'published') AND unpublish_at <= now()`
+
+	getVariantsQuery = "SELECT id,sku,name,price,stock,attributes FROM product_variants WHERE product_id = $1 ORDER BY id ASC"
+	getVariantQuery  = "SELECT sku,name,price,stock,attributes FROM product_variants WHERE id = $1 AND product_id = $2"
+	// getVariantForUpdateQuery locks the row until the end of the transaction, see GetVariantForUpdate
+	getVariantForUpdateQuery = getVariantQuery + " FOR UPDATE"
+	createVariantQuery       = "INSERT INTO product_variants(product_id, sku, name, price, stock, attributes) VALUES($1, $2, $3, $4, $5, $6) RETURNING id"
+	updateVariantQuery       = "UPDATE product_variants SET sku = $1, name = $2, price = $3, stock = $4, attributes = $5 WHERE id = $6 AND product_id = $7"
+	deleteVariantQuery       = "DELETE FROM product_variants WHERE id = $1 AND product_id = $2"
+
+	getTranslationsQuery         = "SELECT locale,name,description FROM product_translations WHERE product_id = $1 ORDER BY locale ASC"
+	getProductsTranslationsQuery = `SELECT product_id,locale,name,description FROM product_translations
+	WHERE product_id = ANY($1) AND locale = ANY($2)`
+	upsertTranslationQuery = `INSERT INTO product_translations(product_id, locale, name, description, search_config)
+	VALUES($1, $2, $3, $4, $5::regconfig)
+	ON CONFLICT (product_id, locale) DO UPDATE
+	SET name = EXCLUDED.name, description = EXCLUDED.description, search_config = EXCLUDED.search_config`
+	deleteTranslationQuery = "DELETE FROM product_translations WHERE product_id = $1 AND locale = $2"
+
+	getCategorySchemasQuery   = "SELECT category,schema FROM category_schemas ORDER BY category ASC"
+	getCategorySchemaQuery    = "SELECT schema FROM category_schemas WHERE category = $1"
+	upsertCategorySchemaQuery = `INSERT INTO category_schemas(category, schema) VALUES($1, $2)
+	ON CONFLICT (tenant_id, category) DO UPDATE SET schema = EXCLUDED.schema`
+	deleteCategorySchemaQuery = "DELETE FROM category_schemas WHERE category = $1"
+
+	getImagesQuery = `SELECT id,storage_key,file_name,content_type,size,checksum,position,is_primary,created_at
+	FROM product_images WHERE product_id = $1 ORDER BY position ASC, id ASC`
+	getImageQuery = `SELECT storage_key,file_name,content_type,size,checksum,position,is_primary,created_at
+	FROM product_images WHERE id = $1 AND product_id = $2`
+	// new images are appended after the last one, the first image of a product becomes its primary
+	createImageQuery = `INSERT INTO product_images(product_id, storage_key, file_name, content_type, size, checksum, position, is_primary)
+	SELECT $1, $2, $3, $4, $5, $6, COALESCE(MAX(position) + 1, 0), COUNT(*) = 0 FROM product_images WHERE product_id = $1
+	RETURNING id,position,is_primary,created_at`
+	clearPrimaryImageQuery = "UPDATE product_images SET is_primary = false WHERE product_id = $1 AND is_primary AND id <> $2"
+	updateImageQuery       = "UPDATE product_images SET position = $1, is_primary = $2 WHERE id = $3 AND product_id = $4"
+	deleteImageQuery       = "DELETE FROM product_images WHERE id = $1 AND product_id = $2 RETURNING storage_key"
+	getImageKeysQuery      = "SELECT storage_key FROM product_images WHERE product_id = $1"
+
+	getReviewsQuery = `SELECT id,author,rating,title,body,status,created_at,moderated_at FROM product_reviews
+	WHERE product_id = $1 AND status = ANY($2) ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4`
+	getReviewQuery = `SELECT author,rating,title,body,status,created_at,moderated_at FROM product_reviews
+	WHERE id = $1 AND product_id = $2`
+	createReviewQuery = `INSERT INTO product_reviews(product_id, author, rating, title, body) VALUES($1, $2, $3, $4, $5)
+	RETURNING id,status,created_at`
+	moderateReviewQuery = `UPDATE product_reviews SET status = $1, moderated_at = now() WHERE id = $2 AND product_id = $3
+	RETURNING moderated_at`
+	deleteReviewQuery = "DELETE FROM product_reviews WHERE id = $1 AND product_id = $2"
+
+	getPromotionsQuery = `SELECT id,name,kind,value,product_ids,categories,starts_at,ends_at,priority,stackable
+	FROM promotions ORDER BY priority DESC, id ASC`
+	getActivePromotionsQuery = `SELECT id,name,kind,value,product_ids,categories,starts_at,ends_at,priority,stackable
+	FROM promotions WHERE (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1)
+	ORDER BY priority DESC, id ASC`
+	getPromotionQuery = `SELECT name,kind,value,product_ids,categories,starts_at,ends_at,priority,stackable
+	FROM promotions WHERE id = $1`
+	createPromotionQuery = `INSERT INTO promotions(name, kind, value, product_ids, categories, starts_at, ends_at, priority, stackable)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	updatePromotionQuery = `UPDATE promotions SET name = $1, kind = $2, value = $3, product_ids = $4, categories = $5,
+	starts_at = $6, ends_at = $7, priority = $8, stackable = $9 WHERE id = $10`
+	deletePromotionQuery = "DELETE FROM promotions WHERE id = $1"
+
+	getApiKeysQuery = `SELECT id,name,prefix,scopes,expires_at,last_used_at,revoked_at,created_at
+	FROM api_keys ORDER BY id ASC`
+	getApiKeyByPrefixQuery = `SELECT id,tenant_id,name,salt,hash,scopes,expires_at,last_used_at,revoked_at,created_at
+	FROM api_keys WHERE prefix = $1`
+	createApiKeyQuery = `INSERT INTO api_keys(name, prefix, salt, hash, scopes, expires_at) VALUES($1, $2, $3, $4, $5, $6)
+	RETURNING id,created_at`
+	// revoking twice keeps the first revocation time
+	revokeApiKeyQuery = "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 RETURNING revoked_at"
+	touchApiKeyQuery  = "UPDATE api_keys SET last_used_at = now() WHERE id = $1"
+
+	countTablesQuery = `SELECT count(*) FROM information_schema.tables
+	WHERE table_schema = current_schema() AND table_name = ANY($1)`
+
+	getCatalogueStatsQuery = `SELECT count(*), COALESCE(avg(price), 0),
+	COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY price), 0) FROM products`
+	// width_bucket numbers the bands from 0, below the first bound, to the number of bounds, above the last one
+	getPriceBandsQuery = "SELECT width_bucket(price, $1::numeric[]), count(*) FROM products GROUP BY 1"
 )
+
+var initDbQueries = append([]string{
+	createTableQuery,
+	addStatusColumnsQuery,
+	createStatusIndexQuery,
+	addAttributesColumnsQuery,
+	createAttributesIndexQuery,
+	createCategorySchemasTableQuery,
+	createVariantsTableQuery,
+	createVariantsIndexQuery,
+	createTranslationsTableQuery,
+	createTranslationsSearchIndexQuery,
+	createProductsSearchIndexQuery,
+	createImagesTableQuery,
+	createImagesIndexQuery,
+	createImagesPrimaryIndexQuery,
+	createPromotionsTableQuery,
+	createReviewsTableQuery,
+	createReviewsIndexQuery,
+	addRatingColumnsQuery,
+	createRatingIndexQuery,
+	createRatingFunctionQuery,
+	dropRatingTriggerQuery,
+	createRatingTriggerQuery,
+	createApiKeysTableQuery,
+}, tenancyQueries()...)
@@ -1,14 +1,29 @@
 package database_test
 
 const (
-	productId    = 42
-	productName  = "sample"
-	productPrice = 42.42
+	productId     = 42
+	productName   = "sample"
+	productPrice  = 42.42
+	productStatus = "published"
+	productCategory   = "t-shirts"
+	productAttributes = `{"color":"red","size":42}`
 
 	productId2    = 43
 	productName2  = "sample-2"
 	productPrice2 = 43.43
 
+	variantId    = 7
+	variantSKU   = "TS-RED-M"
+	variantName  = "M"
+	variantStock = 12
+
+	imageId          = 3
+	imageStorageKey  = "products/42/0a1b2c"
+	imageFileName    = "front.png"
+	imageContentType = "image/png"
+	imageSize        = 2048
+	imageChecksum    = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
 	productNewName  = "new-sample"
 	productNewPrice = 9.90
 )
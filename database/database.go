@@ -26,7 +26,7 @@ const (
 )
 
 func New() (*sql.DB, error) {
-	logging.Log.Info("Create new DB connector")
+	logging.Named(logging.DatabaseLogger).Info("Create new DB connector")
 
 	cfg := LoadConfig()
 
@@ -35,13 +35,13 @@ func New() (*sql.DB, error) {
 		cfg.dbUsername, cfg.dbPassword, cfg.dbName,
 		cfg.dbSslMode,
 	)
-	logging.SugaredLog.Debugf("DB connection string: %s", connString)
+	logging.Named(logging.DatabaseLogger).Debugf("DB connection string: %s", connString)
 
 	return sql.Open(dbDriverName, connString)
 }
 
 func NewWithWrappedTracing() (*sql.DB, error) {
-	logging.Log.Info("Create new DB connector with tracing")
+	logging.Named(logging.DatabaseLogger).Info("Create new DB connector with tracing")
 
 	cfg := LoadConfig()
 
@@ -51,7 +51,7 @@ func NewWithWrappedTracing() (*sql.DB, error) {
 		cfg.dbHost, cfg.dbPort,
 		cfg.dbName, cfg.dbSslMode,
 	)
-	logging.SugaredLog.Debugf("DB connection string: %s", connString)
+	logging.Named(logging.DatabaseLogger).Debugf("DB connection string: %s", connString)
 
 	connector, connErr := pq.NewConnector(connString)
 	if connErr != nil {
@@ -65,7 +65,7 @@ func NewWithWrappedTracing() (*sql.DB, error) {
 			instrumentedsql.WithTracer(instrumentedsqlopentracing.NewTracer()),
 			instrumentedsql.WithLogger(
 				instrumentedsql.LoggerFunc(func(ctx context.Context, msg string, keyvals ...interface{}) {
-					logging.SugaredLog.Infof("%s %v", msg, keyvals)
+					logging.NamedFromContext(logging.DatabaseLogger, ctx).Infof("%s %v", msg, keyvals)
 				})),
 		),
 	)
@@ -74,19 +74,21 @@ func NewWithWrappedTracing() (*sql.DB, error) {
 }
 
 func InitDb(db *sql.DB) error {
-	logging.Log.Info("Initialize DB")
-
-	result, tableErr := db.Exec(createTableQuery)
-	if tableErr != nil {
-		return tableErr
+	logging.Named(logging.DatabaseLogger).Info("Initialize DB")
+
+	for _, query := range initDbQueries {
+		result, queryErr := db.Exec(query)
+		if queryErr != nil {
+			return queryErr
+		}
+		logging.Named(logging.DatabaseLogger).Debugf("Initializion result: %s", result)
 	}
-	logging.SugaredLog.Debugf("Initializion result: %s", result)
 	return nil
 }
 
 func PingDb(db *sql.DB, maxRetry uint64) error {
 	if maxRetry <= 0 {
-		logging.SugaredLog.Warnf("PingDB maxRetry value not valid, falling back to default (%d)", defaultPingMaxRetry)
+		logging.Named(logging.DatabaseLogger).Warnf("PingDB maxRetry value not valid, falling back to default (%d)", defaultPingMaxRetry)
 		maxRetry = defaultPingMaxRetry
 	}
 
@@ -95,10 +97,10 @@ func PingDb(db *sql.DB, maxRetry uint64) error {
 		func() error {
 			err := db.Ping()
 			if err != nil {
-				logging.Log.Info("PostgreSQL connection not ready, backing off...")
+				logging.Named(logging.DatabaseLogger).Info("PostgreSQL connection not ready, backing off...")
 				return err
 			}
-			logging.Log.Info("PostgreSQL connection ready")
+			logging.Named(logging.DatabaseLogger).Info("PostgreSQL connection ready")
 			return nil
 		},
 		backoff.WithMaxRetries(backoff.NewExponentialBackOff(), maxRetry),
@@ -21,13 +21,15 @@ func TestInitDb_Unit_Success(t *testing.T) {
 	db, mock := NewRegexpMock(t)
 	defer db.Close()
 
-	mock.ExpectExec(createTableQuery).
-		WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectCommit()
+	for _, query := range initDbQueries {
+		mock.ExpectExec(query).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
 
 	err := database.InitDb(db)
 
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestInitDb_Unit_Fail(t *testing.T) {
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const (
+	foreignKeyViolationCode = "23503"
+	uniqueViolationCode     = "23505"
+	checkViolationCode      = "23514"
+	queryCanceledCode       = "57014"
+)
+
+// IsUniqueViolation reports whether the error comes from a UNIQUE constraint, e.g. a duplicated SKU.
+func IsUniqueViolation(err error) bool {
+	return hasPqCode(err, uniqueViolationCode)
+}
+
+// IsForeignKeyViolation reports whether the error comes from a FOREIGN KEY constraint, e.g. a missing parent product.
+func IsForeignKeyViolation(err error) bool {
+	return hasPqCode(err, foreignKeyViolationCode)
+}
+
+// IsCheckViolation reports whether the error comes from a CHECK constraint, e.g. a negative stock.
+func IsCheckViolation(err error) bool {
+	return hasPqCode(err, checkViolationCode)
+}
+
+// IsCancelled reports whether the error comes from a cancelled context, or from PostgreSQL cancelling the statement
+// on its request.
+func IsCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		hasPqCode(err, queryCanceledCode)
+}
+
+func hasPqCode(err error, code pq.ErrorCode) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == code
+}
@@ -2,33 +2,28 @@ package database
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
+	"strings"
 
-	"github.com/opentracing/opentracing-go"
+	"github.com/lib/pq"
 )
 
-func GetProducts(db *sql.DB, start, count int, ctx context.Context) ([]*Product, error) {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
-	}
-	span := opentracing.StartSpan(
-		"get-products-db",
-		opentracing.ChildOf(parentCtx),
-	)
+func GetProducts(db Querier, start, count int, filter *ProductsFilter, ctx context.Context) ([]*Product, error) {
+	span := startSpan(ctx, "get-products-db")
 	defer span.Finish()
 
-	span.SetTag("query", getProductsQuery)
+	query, args := buildGetProductsQuery(start, count, filter)
+
+	span.SetTag("query", query)
 	span.SetTag("count", count)
 	span.SetTag("start", start)
 	span.LogKV(
-		"query", getProductsQuery,
+		"query", query,
 		"count", count,
 		"start", start,
 	)
 
-	rows, queryErr := db.QueryContext(ctx, getProductsQuery, count, start)
+	rows, queryErr := db.QueryContext(ctx, query, args...)
 	if queryErr != nil {
 		return nil, queryErr
 	}
@@ -37,10 +32,13 @@ func GetProducts(db *sql.DB, start, count int, ctx context.Context) ([]*Product,
 	products := make([]*Product, 0)
 	for rows.Next() {
 		var prod Product
-		rowErr := rows.Scan(&prod.ID, &prod.Name, &prod.Price)
+		var attributes []byte
+		rowErr := rows.Scan(&prod.ID, &prod.Name, &prod.Price, &prod.Status, &prod.PublishAt, &prod.UnpublishAt,
+			&prod.Category, &attributes, &prod.RatingAverage, &prod.RatingCount)
 		if rowErr != nil {
 			return nil, rowErr
 		}
+		prod.Attributes = attributes
 		products = append(products, &prod)
 	}
 
@@ -50,76 +48,105 @@ func GetProducts(db *sql.DB, start, count int, ctx context.Context) ([]*Product,
 	return products, nil
 }
 
-func GetProduct(db *sql.DB, product *Product, ctx context.Context) error {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
+func buildGetProductsQuery(start, count int, filter *ProductsFilter) (string, []interface{}) {
+	query := getProductsQuery
+	args := make([]interface{}, 0)
+
+	conditions := make([]string, 0)
+	if filter != nil && len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
 	}
-	span := opentracing.StartSpan(
-		"get-product-db",
-		opentracing.ChildOf(parentCtx),
-	)
+	if filter != nil {
+		for _, key := range sortedKeys(filter.Attributes) {
+			candidates := attributeCandidates(key, filter.Attributes[key])
+			matches := make([]string, 0, len(candidates))
+			for _, candidate := range candidates {
+				args = append(args, candidate)
+				matches = append(matches, fmt.Sprintf("attributes @> $%d", len(args)))
+			}
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		}
+	}
+	if filter != nil && filter.Search != "" {
+		args = append(args, filter.Search, pq.Array(filter.Locales))
+		conditions = append(conditions, fmt.Sprintf(getProductsSearchCondition, len(args)-1, len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if filter != nil && filter.Sort == ProductsSortRating {
+		query += getProductsOrderByRating
+	} else {
+		query += getProductsOrderBy
+	}
+
+	args = append(args, count, start)
+	query += fmt.Sprintf(getProductsLimit, len(args)-1, len(args))
+	return query, args
+}
+
+func GetProduct(db Querier, product *Product, ctx context.Context) error {
+	return getProduct(db, getProductQuery, "get-product-db", product, ctx)
+}
+
+// GetProductForUpdate reads the product like GetProduct and locks it until the end of the transaction, so that
+// concurrent updates checked against the same current version cannot both be applied.
+func GetProductForUpdate(db Querier, product *Product, ctx context.Context) error {
+	return getProduct(db, getProductForUpdateQuery, "get-product-for-update-db", product, ctx)
+}
+
+func getProduct(db Querier, query, operationName string, product *Product, ctx context.Context) error {
+	span := startSpan(ctx, operationName)
 	defer span.Finish()
 
 	span.SetTag("product-id", product.ID)
 	span.LogKV("product-id", product.ID)
 
-	return db.QueryRowContext(ctx, getProductQuery, product.ID).
-		Scan(&product.Name, &product.Price)
+	var attributes []byte
+	err := db.QueryRowContext(ctx, query, product.ID).
+		Scan(&product.Name, &product.Price, &product.Status, &product.PublishAt, &product.UnpublishAt,
+			&product.Category, &attributes, &product.RatingAverage, &product.RatingCount)
+	if err != nil {
+		return err
+	}
+	product.Attributes = attributes
+	return nil
 }
 
-func CreateProduct(db *sql.DB, product *Product, ctx context.Context) error {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
-	}
-	span := opentracing.StartSpan(
-		"create-product-db",
-		opentracing.ChildOf(parentCtx),
-	)
+func CreateProduct(db Querier, product *Product, ctx context.Context) error {
+	span := startSpan(ctx, "create-product-db")
 	defer span.Finish()
 
 	span.SetTag("product", product.String())
 	span.LogKV("product", product.String())
 
-	err := db.QueryRowContext(ctx, createProductQuery, product.Name, product.Price).Scan(&product.ID)
+	err := db.QueryRowContext(ctx, createProductQuery,
+		product.Name, product.Price, product.Status, product.PublishAt, product.UnpublishAt,
+		product.Category, attributesOrEmpty(product.Attributes)).
+		Scan(&product.ID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func UpdateProduct(db *sql.DB, product *Product, ctx context.Context) error {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
-	}
-	span := opentracing.StartSpan(
-		"update-product-db",
-		opentracing.ChildOf(parentCtx),
-	)
+func UpdateProduct(db Querier, product *Product, ctx context.Context) error {
+	span := startSpan(ctx, "update-product-db")
 	defer span.Finish()
 
 	span.SetTag("product", product.String())
 	span.LogKV("product", product.String())
 
-	_, err := db.ExecContext(ctx, updateProductQuery, product.Name, product.Price, product.ID)
+	_, err := db.ExecContext(ctx, updateProductQuery,
+		product.Name, product.Price, product.Status, product.PublishAt, product.UnpublishAt,
+		product.Category, attributesOrEmpty(product.Attributes), product.ID)
 	return err
 }
 
-func DeleteProduct(db *sql.DB, productId int, ctx context.Context) error {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
-	}
-	span := opentracing.StartSpan(
-		"delete-product-db",
-		opentracing.ChildOf(parentCtx),
-	)
+func DeleteProduct(db Querier, productId int, ctx context.Context) error {
+	span := startSpan(ctx, "delete-product-db")
 	defer span.Finish()
 
 	span.SetTag("product-id", productId)
@@ -129,16 +156,8 @@ func DeleteProduct(db *sql.DB, productId int, ctx context.Context) error {
 	return err
 }
 
-func DeleteProducts(db *sql.DB, ctx context.Context) error {
-	parentSpan := opentracing.SpanFromContext(ctx)
-	var parentCtx opentracing.SpanContext
-	if parentSpan != nil {
-		parentCtx = parentSpan.Context()
-	}
-	span := opentracing.StartSpan(
-		"delete-products-db",
-		opentracing.ChildOf(parentCtx),
-	)
+func DeleteProducts(db Querier, ctx context.Context) error {
+	span := startSpan(ctx, "delete-products-db")
 	defer span.Finish()
 
 	span.SetTag("query", deleteProductsQuery)
@@ -146,3 +165,41 @@ func DeleteProducts(db *sql.DB, ctx context.Context) error {
 	_, err := db.ExecContext(ctx, deleteProductsQuery)
 	return err
 }
+
+// PublishScheduledProducts moves scheduled products whose publish_at is reached to published.
+func PublishScheduledProducts(db Querier, ctx context.Context) (int64, error) {
+	span := startSpan(ctx, "publish-scheduled-products-db")
+	defer span.Finish()
+
+	span.SetTag("query", publishScheduledProductsQuery)
+
+	result, err := db.ExecContext(ctx, publishScheduledProductsQuery)
+	if err != nil {
+		return 0, err
+	}
+	published, _ := result.RowsAffected()
+
+	span.SetTag("products-published", published)
+	span.LogKV("products-published", published)
+
+	return published, nil
+}
+
+// ArchiveExpiredProducts moves scheduled or published products whose unpublish_at is reached to archived.
+func ArchiveExpiredProducts(db Querier, ctx context.Context) (int64, error) {
+	span := startSpan(ctx, "archive-expired-products-db")
+	defer span.Finish()
+
+	span.SetTag("query", archiveExpiredProductsQuery)
+
+	result, err := db.ExecContext(ctx, archiveExpiredProductsQuery)
+	if err != nil {
+		return 0, err
+	}
+	archived, _ := result.RowsAffected()
+
+	span.SetTag("products-archived", archived)
+	span.LogKV("products-archived", archived)
+
+	return archived, nil
+}
@@ -4,7 +4,9 @@ package database_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,7 +23,7 @@ func TestGetProducts_Integr_Success(t *testing.T) {
 	insertErr := database.CreateProduct(db, product, ctx)
 	require.NoError(t, insertErr)
 
-	products, err := database.GetProducts(db, 0, 10, ctx)
+	products, err := database.GetProducts(db, 0, 10, nil, ctx)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, products)
 	assert.Len(t, products, 1)
@@ -122,16 +124,135 @@ func TestDeleteProducts_Integr_Success(t *testing.T) {
 	insert3Err := database.CreateProduct(db, product3, ctx)
 	require.NoError(t, insert3Err)
 
-	productsBefore, getErrBefore := database.GetProducts(db, 0, 10, ctx)
+	productsBefore, getErrBefore := database.GetProducts(db, 0, 10, nil, ctx)
 	require.NoError(t, getErrBefore)
 	require.Len(t, productsBefore, 3)
 
 	err := database.DeleteProducts(db, ctx)
 	assert.NoError(t, err)
 
-	productsAfter, getErrAfter := database.GetProducts(db, 0, 10, ctx)
+	productsAfter, getErrAfter := database.GetProducts(db, 0, 10, nil, ctx)
 	assert.NoError(t, getErrAfter)
 	assert.Len(t, productsAfter, 0)
 
 	database.DeleteProducts(db, ctx)
 }
+
+func TestPublishScheduledProducts_Integr_Success(t *testing.T) {
+	ctx := context.Background()
+
+	db := initConnAndTable(t)
+
+	publishAt := time.Now().Add(-time.Minute)
+	product := &database.Product{Name: productName, Price: productPrice,
+		Status: database.ProductStatusScheduled, PublishAt: &publishAt}
+	insertErr := database.CreateProduct(db, product, ctx)
+	require.NoError(t, insertErr)
+
+	published, err := database.PublishScheduledProducts(db, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+
+	getErr := database.GetProduct(db, product, ctx)
+	require.NoError(t, getErr)
+	assert.Equal(t, database.ProductStatusPublished, product.Status)
+
+	database.DeleteProducts(db, ctx)
+}
+
+func TestGetProducts_Integr_Success_AttributesFilter(t *testing.T) {
+	ctx := context.Background()
+
+	db := initConnAndTable(t)
+
+	red := &database.Product{Name: productName, Price: productPrice, Attributes: json.RawMessage(`{"color":"red","size":42}`)}
+	insertErr := database.CreateProduct(db, red, ctx)
+	require.NoError(t, insertErr)
+
+	blue := &database.Product{Name: productName2, Price: productPrice2, Attributes: json.RawMessage(`{"color":"blue","size":42}`)}
+	insert2Err := database.CreateProduct(db, blue, ctx)
+	require.NoError(t, insert2Err)
+
+	filter := &database.ProductsFilter{Attributes: map[string]string{"color": "red", "size": "42"}}
+	products, err := database.GetProducts(db, 0, 10, filter, ctx)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, red.ID, products[0].ID)
+
+	database.DeleteProducts(db, ctx)
+}
+
+func TestModerateReview_Integr_Success_Rating(t *testing.T) {
+	ctx := context.Background()
+
+	db := initConnAndTable(t)
+
+	product := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusPublished}
+	insertErr := database.CreateProduct(db, product, ctx)
+	require.NoError(t, insertErr)
+
+	for _, rating := range []int{5, 4, 1} {
+		review := &database.Review{ProductID: product.ID, Author: productName, Rating: rating}
+		reviewErr := database.CreateReview(db, review, ctx)
+		require.NoError(t, reviewErr)
+
+		// the 1 star review stays pending, so it does not count
+		if rating > 1 {
+			review.Status = database.ReviewStatusApproved
+			moderateErr := database.ModerateReview(db, review, ctx)
+			require.NoError(t, moderateErr)
+		}
+	}
+
+	getErr := database.GetProduct(db, product, ctx)
+	require.NoError(t, getErr)
+	assert.Equal(t, 4.5, product.RatingAverage)
+	assert.Equal(t, 2, product.RatingCount)
+
+	database.DeleteProducts(db, ctx)
+}
+
+func TestBeginTenantTx_Integr_Success_Isolation(t *testing.T) {
+	ctx := context.Background()
+
+	db := initConnAndTable(t)
+
+	acmeTx, acmeErr := database.BeginTenantTx(db, "acme", ctx)
+	require.NoError(t, acmeErr)
+	product := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusPublished}
+	insertErr := database.CreateProduct(acmeTx, product, ctx)
+	require.NoError(t, insertErr)
+	require.NoError(t, acmeTx.Commit())
+
+	otherTx, otherErr := database.BeginTenantTx(db, "other", ctx)
+	require.NoError(t, otherErr)
+	defer otherTx.Rollback()
+
+	// no WHERE clause on the tenant, row level security filters the rows anyway
+	products, getErr := database.GetProducts(otherTx, 0, 10, nil, ctx)
+	assert.NoError(t, getErr)
+	assert.Empty(t, products)
+
+	variant := &database.Variant{ProductID: product.ID, SKU: variantSKU}
+	variantErr := database.CreateVariant(otherTx, variant, ctx)
+	assert.True(t, database.IsForeignKeyViolation(variantErr))
+
+	database.DeleteProducts(db, ctx)
+}
+
+func TestBeginTenantTx_Integr_Cancelled_Deadline(t *testing.T) {
+	db := initConnAndTable(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	tx, txErr := database.BeginTenantTx(db, "acme", ctx)
+	require.NoError(t, txErr)
+	defer tx.Rollback()
+
+	// PostgreSQL must stop the statement itself, not only the client giving up on it
+	start := time.Now()
+	_, sleepErr := tx.ExecContext(ctx, "SELECT pg_sleep(10)")
+	assert.True(t, database.IsCancelled(sleepErr), "unexpected error: %v", sleepErr)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
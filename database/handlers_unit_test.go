@@ -4,10 +4,13 @@ package database_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -22,14 +25,14 @@ func TestGetProducts_Unit_Success(t *testing.T) {
 	db, mock := NewRegexpMock(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "name", "price"}).
-		AddRow(productId, productName, productPrice).
-		AddRow(productId2, productName2, productPrice2)
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, productAttributes, "0.00", 0).
+		AddRow(productId2, productName2, productPrice2, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
 
 	mock.ExpectQuery(getProductsQuery).
 		WillReturnRows(rows)
 
-	products, err := database.GetProducts(db, 0, 10, context.Background())
+	products, err := database.GetProducts(db, 0, 10, nil, context.Background())
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, products)
@@ -42,6 +45,96 @@ func TestGetProducts_Unit_Success(t *testing.T) {
 	assert.Equal(t, productPrice2, products[1].Price)
 }
 
+func TestGetProducts_Unit_Success_StatusFilter(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewEqualMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
+
+	mock.ExpectQuery(getProductsQuery+" WHERE status = ANY($1) ORDER BY id ASC LIMIT $2 OFFSET $3").
+		WithArgs(pq.Array([]string{productStatus}), 10, 0).
+		WillReturnRows(rows)
+
+	filter := &database.ProductsFilter{Statuses: []string{productStatus}}
+	products, err := database.GetProducts(db, 0, 10, filter, context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, productStatus, products[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProducts_Unit_Success_SortByRating(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewEqualMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "4.50", 2)
+
+	mock.ExpectQuery(getProductsQuery+" ORDER BY rating_average DESC, rating_count DESC, id ASC LIMIT $1 OFFSET $2").
+		WithArgs(10, 0).
+		WillReturnRows(rows)
+
+	filter := &database.ProductsFilter{Sort: database.ProductsSortRating}
+	products, err := database.GetProducts(db, 0, 10, filter, context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, 4.5, products[0].RatingAverage)
+	assert.Equal(t, 2, products[0].RatingCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProducts_Unit_Success_AttributesFilter(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewEqualMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
+
+	mock.ExpectQuery(getProductsQuery+" WHERE (attributes @> $1) AND (attributes @> $2 OR attributes @> $3) ORDER BY id ASC LIMIT $4 OFFSET $5").
+		WithArgs(`{"color":"red"}`, `{"size":"42"}`, `{"size":42}`, 10, 0).
+		WillReturnRows(rows)
+
+	filter := &database.ProductsFilter{Attributes: map[string]string{"size": "42", "color": "red"}}
+	products, err := database.GetProducts(db, 0, 10, filter, context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProducts_Unit_Success_Search(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta("plainto_tsquery(t.search_config, $1)")).
+		WithArgs("shirt", pq.Array([]string{"de", "en"}), 10, 0).
+		WillReturnRows(rows)
+
+	filter := &database.ProductsFilter{Search: "shirt", Locales: []string{"de", "en"}}
+	products, err := database.GetProducts(db, 0, 10, filter, context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
 func TestGetProducts_Unit_Fail_Query(t *testing.T) {
 	logErr := logging.InitGlobalLogger()
 	require.NoError(t, logErr)
@@ -52,7 +145,7 @@ func TestGetProducts_Unit_Fail_Query(t *testing.T) {
 	mock.ExpectQuery(getProductsQuery).
 		WillReturnError(fmt.Errorf("error"))
 
-	products, err := database.GetProducts(db, 0, 10, context.Background())
+	products, err := database.GetProducts(db, 0, 10, nil, context.Background())
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(products))
@@ -66,15 +159,15 @@ func TestGetProducts_Unit_Fail_Scan(t *testing.T) {
 	db, mock := NewRegexpMock(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "name", "price"}).
-		AddRow(productId, productName, productPrice).
-		AddRow(productId2, productName2, productPrice2).
-		AddRow(nil, "sample-3", 44.44).RowError(3, fmt.Errorf("row-error"))
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productId, productName, productPrice, productStatus, nil, nil, productCategory, productAttributes, "0.00", 0).
+		AddRow(productId2, productName2, productPrice2, productStatus, nil, nil, productCategory, productAttributes, "0.00", 0).
+		AddRow(nil, "sample-3", 44.44, productStatus, nil, nil, productCategory, productAttributes, "0.00", 0).RowError(3, fmt.Errorf("row-error"))
 
 	mock.ExpectQuery(getProductsQuery).
 		WillReturnRows(rows)
 
-	products, err := database.GetProducts(db, 0, 10, context.Background())
+	products, err := database.GetProducts(db, 0, 10, nil, context.Background())
 
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(products))
@@ -87,8 +180,8 @@ func TestGetProduct_Unit_Success(t *testing.T) {
 	db, mock := NewRegexpMock(t)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"name", "price"}).
-		AddRow(productName, productPrice)
+	rows := sqlmock.NewRows([]string{"name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
 
 	mock.ExpectQuery(getProductQuery).
 		WithArgs(productId).
@@ -101,6 +194,9 @@ func TestGetProduct_Unit_Success(t *testing.T) {
 	assert.Equal(t, productId, product.ID)
 	assert.Equal(t, productName, product.Name)
 	assert.Equal(t, productPrice, product.Price)
+	assert.Equal(t, productStatus, product.Status)
+	assert.Equal(t, productCategory, product.Category)
+	assert.JSONEq(t, productAttributes, string(product.Attributes))
 }
 
 func TestGetProduct_Unit_Fail(t *testing.T) {
@@ -123,6 +219,29 @@ func TestGetProduct_Unit_Fail(t *testing.T) {
 	assert.Equal(t, 0.0, product.Price)
 }
 
+func TestGetProductForUpdate_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewEqualMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"name", "price", "status", "publish_at", "unpublish_at", "category", "attributes", "rating_average", "rating_count"}).
+		AddRow(productName, productPrice, productStatus, nil, nil, productCategory, []byte(productAttributes), "0.00", 0)
+
+	mock.ExpectQuery(getProductQuery + " WHERE id = $1 FOR UPDATE").
+		WithArgs(productId).
+		WillReturnRows(rows)
+
+	product := &database.Product{ID: productId}
+	err := database.GetProductForUpdate(db, product, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, productName, product.Name)
+	assert.Equal(t, productPrice, product.Price)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCreateProduct_Unit_Success(t *testing.T) {
 	logErr := logging.InitGlobalLogger()
 	require.NoError(t, logErr)
@@ -134,11 +253,12 @@ func TestCreateProduct_Unit_Success(t *testing.T) {
 		AddRow(productId)
 
 	mock.ExpectQuery(createProductQuery).
-		WithArgs(productName, productPrice).
+		WithArgs(productName, productPrice, productStatus, nil, nil, productCategory, productAttributes).
 		WillReturnRows(rows)
 	mock.ExpectCommit()
 
-	product := &database.Product{Name: productName, Price: productPrice}
+	product := &database.Product{Name: productName, Price: productPrice, Status: productStatus,
+		Category: productCategory, Attributes: json.RawMessage(productAttributes)}
 	err := database.CreateProduct(db, product, context.Background())
 
 	assert.NoError(t, err)
@@ -155,11 +275,12 @@ func TestCreateProduct_Unit_Fail(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectQuery(createProductQuery).
-		WithArgs(productName, productPrice).
+		WithArgs(productName, productPrice, productStatus, nil, nil, productCategory, productAttributes).
 		WillReturnError(fmt.Errorf("error"))
 	mock.ExpectRollback()
 
-	product := &database.Product{Name: productName, Price: productPrice}
+	product := &database.Product{Name: productName, Price: productPrice, Status: productStatus,
+		Category: productCategory, Attributes: json.RawMessage(productAttributes)}
 	err := database.CreateProduct(db, product, context.Background())
 
 	assert.Error(t, err)
@@ -173,11 +294,12 @@ func TestUpdateProduct_Unit_Success(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectExec(updateProductQuery).
-		WithArgs(productName, productPrice, productId).
+		WithArgs(productName, productPrice, productStatus, nil, nil, productCategory, productAttributes, productId).
 		WillReturnResult(sqlmock.NewResult(productId, 1))
 	mock.ExpectCommit()
 
-	product := &database.Product{ID: productId, Name: productName, Price: productPrice}
+	product := &database.Product{ID: productId, Name: productName, Price: productPrice, Status: productStatus,
+		Category: productCategory, Attributes: json.RawMessage(productAttributes)}
 	err := database.UpdateProduct(db, product, context.Background())
 
 	assert.NoError(t, err)
@@ -191,11 +313,12 @@ func TestUpdateProduct_Unit_Fail(t *testing.T) {
 	defer db.Close()
 
 	mock.ExpectExec(updateProductQuery).
-		WithArgs(productName, productPrice, productId).
+		WithArgs(productName, productPrice, productStatus, nil, nil, productCategory, productAttributes, productId).
 		WillReturnError(fmt.Errorf("error"))
 	mock.ExpectRollback()
 
-	product := &database.Product{ID: productId, Name: productName, Price: productPrice}
+	product := &database.Product{ID: productId, Name: productName, Price: productPrice, Status: productStatus,
+		Category: productCategory, Attributes: json.RawMessage(productAttributes)}
 	err := database.UpdateProduct(db, product, context.Background())
 
 	assert.Error(t, err)
@@ -267,3 +390,51 @@ func TestDeleteProducts_Unit_Fail(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestPublishScheduledProducts_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(publishProductsQuery).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	published, err := database.PublishScheduledProducts(db, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), published)
+}
+
+func TestPublishScheduledProducts_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(publishProductsQuery).
+		WillReturnError(fmt.Errorf("error"))
+
+	published, err := database.PublishScheduledProducts(db, context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), published)
+}
+
+func TestArchiveExpiredProducts_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(archiveProductsQuery).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	archived, err := database.ArchiveExpiredProducts(db, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), archived)
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// CheckConnection pings the DB, failing once the context is done.
+func CheckConnection(db *sql.DB, ctx context.Context) error {
+	return db.PingContext(ctx)
+}
+
+// CheckPoolSaturation fails when the share of open connections in use reaches the threshold, between 0 and 1.
+// Pools without a maximum of open connections never saturate.
+func CheckPoolSaturation(db *sql.DB, threshold float64) error {
+	saturation := PoolSaturation(db.Stats())
+	if saturation >= threshold {
+		return fmt.Errorf("connection pool saturated: %.0f%% of connections in use", saturation*100)
+	}
+	return nil
+}
+
+// PoolSaturation returns the share of the maximum open connections in use, 0 if there is no maximum.
+func PoolSaturation(stats sql.DBStats) float64 {
+	if stats.MaxOpenConnections <= 0 {
+		return 0
+	}
+	return float64(stats.InUse) / float64(stats.MaxOpenConnections)
+}
+
+// CheckMigrations fails if any table created by InitDb is missing from the schema.
+func CheckMigrations(db Querier, ctx context.Context) error {
+	var count int
+	err := db.QueryRowContext(ctx, countTablesQuery, pq.Array(tenantTables)).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count < len(tenantTables) {
+		return fmt.Errorf("migrations not applied: %d of %d tables found", count, len(tenantTables))
+	}
+	return nil
+}
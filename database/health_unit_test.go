@@ -0,0 +1,46 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+func TestCheckMigrations_Unit_Success(t *testing.T) {
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(countTablesQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+
+	err := database.CheckMigrations(db, context.Background())
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckMigrations_Unit_Fail(t *testing.T) {
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(countTablesQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+	err := database.CheckMigrations(db, context.Background())
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPoolSaturation_Unit(t *testing.T) {
+	assert.Equal(t, 0.0, database.PoolSaturation(sql.DBStats{InUse: 7}))
+	assert.Equal(t, 0.5, database.PoolSaturation(sql.DBStats{MaxOpenConnections: 10, InUse: 5}))
+	assert.Equal(t, 1.0, database.PoolSaturation(sql.DBStats{MaxOpenConnections: 10, InUse: 10}))
+}
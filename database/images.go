@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+)
+
+func GetImages(db Querier, productId int, ctx context.Context) ([]*Image, error) {
+	span := startSpan(ctx, "get-images-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.LogKV("product-id", productId)
+
+	rows, queryErr := db.QueryContext(ctx, getImagesQuery, productId)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	images := make([]*Image, 0)
+	for rows.Next() {
+		image := Image{ProductID: productId}
+		rowErr := rows.Scan(&image.ID, &image.StorageKey, &image.FileName, &image.ContentType, &image.Size,
+			&image.Checksum, &image.Position, &image.Primary, &image.CreatedAt)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		images = append(images, &image)
+	}
+
+	span.SetTag("images-found", len(images))
+	span.LogKV("images-found", len(images))
+
+	return images, nil
+}
+
+func GetImage(db Querier, image *Image, ctx context.Context) error {
+	span := startSpan(ctx, "get-image-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", image.ProductID)
+	span.SetTag("image-id", image.ID)
+	span.LogKV("product-id", image.ProductID, "image-id", image.ID)
+
+	return db.QueryRowContext(ctx, getImageQuery, image.ID, image.ProductID).
+		Scan(&image.StorageKey, &image.FileName, &image.ContentType, &image.Size,
+			&image.Checksum, &image.Position, &image.Primary, &image.CreatedAt)
+}
+
+// CreateImage appends the image after the existing ones of the product, making it primary if it is the first.
+func CreateImage(db Querier, image *Image, ctx context.Context) error {
+	span := startSpan(ctx, "create-image-db")
+	defer span.Finish()
+
+	span.SetTag("image", image.String())
+	span.LogKV("image", image.String())
+
+	return db.QueryRowContext(ctx, createImageQuery,
+		image.ProductID, image.StorageKey, image.FileName, image.ContentType, image.Size, image.Checksum).
+		Scan(&image.ID, &image.Position, &image.Primary, &image.CreatedAt)
+}
+
+// UpdateImage changes position and primary flag of the image, a new primary image replaces the previous one.
+// The two statements involved should run in a transaction, as request handlers do.
+// It returns sql.ErrNoRows if the image does not exist under the given product.
+func UpdateImage(db Querier, image *Image, ctx context.Context) error {
+	span := startSpan(ctx, "update-image-db")
+	defer span.Finish()
+
+	span.SetTag("image", image.String())
+	span.LogKV("image", image.String())
+
+	if image.Primary {
+		// the partial unique index allows a single primary image, so the previous one is cleared first
+		_, clearErr := db.ExecContext(ctx, clearPrimaryImageQuery, image.ProductID, image.ID)
+		if clearErr != nil {
+			return clearErr
+		}
+	}
+
+	result, updateErr := db.ExecContext(ctx, updateImageQuery, image.Position, image.Primary, image.ID, image.ProductID)
+	if updateErr != nil {
+		return updateErr
+	}
+	return expectAffectedRows(result)
+}
+
+// DeleteImage returns the storage key of the deleted image, so that its content can be removed as well,
+// or sql.ErrNoRows if the image does not exist under the given product.
+func DeleteImage(db Querier, productId, imageId int, ctx context.Context) (string, error) {
+	span := startSpan(ctx, "delete-image-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.SetTag("image-id", imageId)
+	span.LogKV("product-id", productId, "image-id", imageId)
+
+	var storageKey string
+	err := db.QueryRowContext(ctx, deleteImageQuery, imageId, productId).Scan(&storageKey)
+	return storageKey, err
+}
+
+// GetImageKeys returns the storage keys of all the images of the product.
+func GetImageKeys(db Querier, productId int, ctx context.Context) ([]string, error) {
+	span := startSpan(ctx, "get-image-keys-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.LogKV("product-id", productId)
+
+	rows, queryErr := db.QueryContext(ctx, getImageKeysQuery, productId)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		rowErr := rows.Scan(&key)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		keys = append(keys, key)
+	}
+
+	span.SetTag("images-found", len(keys))
+	span.LogKV("images-found", len(keys))
+
+	return keys, nil
+}
@@ -0,0 +1,143 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestGetImages_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "storage_key", "file_name", "content_type", "size", "checksum", "position", "is_primary", "created_at"}).
+		AddRow(imageId, imageStorageKey, imageFileName, imageContentType, imageSize, imageChecksum, 0, true, now).
+		AddRow(imageId+1, imageStorageKey+"-2", imageFileName, imageContentType, imageSize, imageChecksum, 1, false, now)
+
+	mock.ExpectQuery(getImagesQuery).
+		WithArgs(productId).
+		WillReturnRows(rows)
+
+	images, err := database.GetImages(db, productId, context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, productId, images[0].ProductID)
+	assert.Equal(t, imageStorageKey, images[0].StorageKey)
+	assert.True(t, images[0].Primary)
+	assert.Equal(t, 1, images[1].Position)
+}
+
+func TestCreateImage_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "position", "is_primary", "created_at"}).
+		AddRow(imageId, 2, false, time.Now())
+
+	mock.ExpectQuery(createImageQuery).
+		WithArgs(productId, imageStorageKey, imageFileName, imageContentType, imageSize, imageChecksum).
+		WillReturnRows(rows)
+
+	image := &database.Image{ProductID: productId, StorageKey: imageStorageKey, FileName: imageFileName,
+		ContentType: imageContentType, Size: imageSize, Checksum: imageChecksum}
+	err := database.CreateImage(db, image, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, imageId, image.ID)
+	assert.Equal(t, 2, image.Position)
+	assert.False(t, image.Primary)
+}
+
+func TestUpdateImage_Unit_Primary(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(clearPrimaryImageQuery).
+		WithArgs(productId, imageId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateImageQuery).
+		WithArgs(1, true, imageId, productId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	image := &database.Image{ID: imageId, ProductID: productId, Position: 1, Primary: true}
+	err := database.UpdateImage(db, image, context.Background())
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateImage_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(updateImageQuery).
+		WithArgs(1, false, imageId, productId).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	image := &database.Image{ID: imageId, ProductID: productId, Position: 1}
+	err := database.UpdateImage(db, image, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteImage_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"storage_key"}).
+		AddRow(imageStorageKey)
+
+	mock.ExpectQuery(deleteImageQuery).
+		WithArgs(imageId, productId).
+		WillReturnRows(rows)
+
+	storageKey, err := database.DeleteImage(db, productId, imageId, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, imageStorageKey, storageKey)
+}
+
+func TestDeleteImage_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(deleteImageQuery).
+		WithArgs(imageId, productId).
+		WillReturnError(fmt.Errorf("error"))
+
+	_, err := database.DeleteImage(db, productId, imageId, context.Background())
+
+	assert.Error(t, err)
+}
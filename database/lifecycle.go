@@ -0,0 +1,50 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+func IsValidProductStatus(status string) bool {
+	switch status {
+	case ProductStatusDraft, ProductStatusScheduled, ProductStatusPublished, ProductStatusArchived:
+		return true
+	default:
+		return false
+	}
+}
+
+// PrepareLifecycle fills in the missing status of a new product and checks that status and publishing window are
+// consistent. A product without status is scheduled when it carries a future publish_at, otherwise it starts as draft.
+func (p *Product) PrepareLifecycle(now time.Time) error {
+	if p.Status == "" {
+		if p.PublishAt != nil && p.PublishAt.After(now) {
+			p.Status = ProductStatusScheduled
+		} else {
+			p.Status = ProductStatusDraft
+		}
+	}
+	return p.validateLifecycle()
+}
+
+// PrepareUpdateLifecycle keeps the current status when the update carries none, so that clients unaware of the
+// lifecycle don't unpublish the products they update, and checks that status and publishing window are consistent.
+func (p *Product) PrepareUpdateLifecycle(current *Product) error {
+	if p.Status == "" {
+		p.Status = current.Status
+	}
+	return p.validateLifecycle()
+}
+
+func (p *Product) validateLifecycle() error {
+	if !IsValidProductStatus(p.Status) {
+		return fmt.Errorf("status %q not valid", p.Status)
+	}
+	if p.Status == ProductStatusScheduled && p.PublishAt == nil {
+		return fmt.Errorf("status %q requires publish_at", ProductStatusScheduled)
+	}
+	if p.PublishAt != nil && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		return fmt.Errorf("unpublish_at must be after publish_at")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// +build !integration
+
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+func TestPrepareLifecycle_Unit_DefaultDraft(t *testing.T) {
+	product := &database.Product{Name: productName, Price: productPrice}
+
+	err := product.PrepareLifecycle(time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, database.ProductStatusDraft, product.Status)
+}
+
+func TestPrepareLifecycle_Unit_DefaultScheduled(t *testing.T) {
+	now := time.Now()
+	publishAt := now.Add(time.Hour)
+	product := &database.Product{Name: productName, Price: productPrice, PublishAt: &publishAt}
+
+	err := product.PrepareLifecycle(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, database.ProductStatusScheduled, product.Status)
+}
+
+func TestPrepareLifecycle_Unit_Fail_InvalidStatus(t *testing.T) {
+	product := &database.Product{Name: productName, Price: productPrice, Status: "unknown"}
+
+	err := product.PrepareLifecycle(time.Now())
+
+	assert.Error(t, err)
+}
+
+func TestPrepareLifecycle_Unit_Fail_ScheduledWithoutPublishAt(t *testing.T) {
+	product := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusScheduled}
+
+	err := product.PrepareLifecycle(time.Now())
+
+	assert.Error(t, err)
+}
+
+func TestPrepareLifecycle_Unit_Fail_UnpublishBeforePublish(t *testing.T) {
+	now := time.Now()
+	publishAt := now.Add(time.Hour)
+	unpublishAt := now
+	product := &database.Product{Name: productName, Price: productPrice, PublishAt: &publishAt, UnpublishAt: &unpublishAt}
+
+	err := product.PrepareLifecycle(now)
+
+	assert.Error(t, err)
+}
+
+func TestPrepareUpdateLifecycle_Unit_KeepCurrentStatus(t *testing.T) {
+	current := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusPublished}
+	product := &database.Product{Name: productName, Price: productPrice}
+
+	err := product.PrepareUpdateLifecycle(current)
+
+	assert.NoError(t, err)
+	assert.Equal(t, database.ProductStatusPublished, product.Status)
+}
+
+func TestPrepareUpdateLifecycle_Unit_ChangeStatus(t *testing.T) {
+	current := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusPublished}
+	product := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusArchived}
+
+	err := product.PrepareUpdateLifecycle(current)
+
+	assert.NoError(t, err)
+	assert.Equal(t, database.ProductStatusArchived, product.Status)
+}
+
+func TestPrepareUpdateLifecycle_Unit_Fail_ScheduledWithoutPublishAt(t *testing.T) {
+	current := &database.Product{Name: productName, Price: productPrice, Status: database.ProductStatusScheduled}
+	product := &database.Product{Name: productName, Price: productPrice}
+
+	err := product.PrepareUpdateLifecycle(current)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "gotraces"
+	subsystem = "db"
+
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+var (
+	queryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "query_duration_seconds",
+			Help:      "Duration of DB operations in seconds, by operation name",
+			Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"operation"},
+	)
+
+	openConnectionsDesc = newStatsDesc("open_connections",
+		"Number of established connections, both in use and idle")
+	inUseConnectionsDesc = newStatsDesc("in_use_connections",
+		"Number of connections currently in use")
+	idleConnectionsDesc = newStatsDesc("idle_connections",
+		"Number of idle connections")
+	maxOpenConnectionsDesc = newStatsDesc("max_open_connections",
+		"Maximum number of open connections, 0 for unlimited")
+	waitCountDesc = newStatsDesc("wait_count_total",
+		"Total number of connections waited for")
+	waitDurationDesc = newStatsDesc("wait_duration_seconds_total",
+		"Total time blocked waiting for a new connection in seconds")
+	maxIdleClosedDesc = newStatsDesc("max_idle_closed_total",
+		"Total number of connections closed due to the maximum of idle connections")
+	maxIdleTimeClosedDesc = newStatsDesc("max_idle_time_closed_total",
+		"Total number of connections closed due to the maximum idle time")
+	maxLifetimeClosedDesc = newStatsDesc("max_lifetime_closed_total",
+		"Total number of connections closed due to the maximum connection lifetime")
+)
+
+// statsCollector exports the connection pool statistics of the DBs, read at every scrape, labelled by DB role.
+type statsCollector struct {
+	dbs map[string]*sql.DB
+}
+
+// Metrics returns the collectors of the DB metrics: the pool statistics of the DBs by role, e.g. RolePrimary, and
+// the durations of the operations, by the names of their spans.
+func Metrics(dbs map[string]*sql.DB) []prometheus.Collector {
+	return []prometheus.Collector{&statsCollector{dbs: dbs}, queryDuration}
+}
+
+func (c *statsCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- openConnectionsDesc
+	descs <- inUseConnectionsDesc
+	descs <- idleConnectionsDesc
+	descs <- maxOpenConnectionsDesc
+	descs <- waitCountDesc
+	descs <- waitDurationDesc
+	descs <- maxIdleClosedDesc
+	descs <- maxIdleTimeClosedDesc
+	descs <- maxLifetimeClosedDesc
+}
+
+func (c *statsCollector) Collect(metrics chan<- prometheus.Metric) {
+	for role, db := range c.dbs {
+		stats := db.Stats()
+		metrics <- prometheus.MustNewConstMetric(openConnectionsDesc, prometheus.GaugeValue, float64(stats.OpenConnections), role)
+		metrics <- prometheus.MustNewConstMetric(inUseConnectionsDesc, prometheus.GaugeValue, float64(stats.InUse), role)
+		metrics <- prometheus.MustNewConstMetric(idleConnectionsDesc, prometheus.GaugeValue, float64(stats.Idle), role)
+		metrics <- prometheus.MustNewConstMetric(maxOpenConnectionsDesc, prometheus.GaugeValue, float64(stats.MaxOpenConnections), role)
+		metrics <- prometheus.MustNewConstMetric(waitCountDesc, prometheus.CounterValue, float64(stats.WaitCount), role)
+		metrics <- prometheus.MustNewConstMetric(waitDurationDesc, prometheus.CounterValue, stats.WaitDuration.Seconds(), role)
+		metrics <- prometheus.MustNewConstMetric(maxIdleClosedDesc, prometheus.CounterValue, float64(stats.MaxIdleClosed), role)
+		metrics <- prometheus.MustNewConstMetric(maxIdleTimeClosedDesc, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed), role)
+		metrics <- prometheus.MustNewConstMetric(maxLifetimeClosedDesc, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), role)
+	}
+}
+
+func newStatsDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, []string{"role"}, nil)
+}
@@ -0,0 +1,78 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestMetrics_Unit_PoolStats(t *testing.T) {
+	db, _ := NewRegexpMock(t)
+	defer db.Close()
+	db.SetMaxOpenConns(7)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(database.Metrics(map[string]*sql.DB{database.RolePrimary: db})[0]))
+
+	expected := `
+# HELP gotraces_db_max_open_connections Maximum number of open connections, 0 for unlimited
+# TYPE gotraces_db_max_open_connections gauge
+gotraces_db_max_open_connections{role="primary"} 7
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "gotraces_db_max_open_connections")
+	assert.NoError(t, err)
+
+	count, countErr := testutil.GatherAndCount(registry)
+	assert.NoError(t, countErr)
+	assert.Equal(t, 9, count)
+}
+
+func TestMetrics_Unit_QueryDuration(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deleteProductQuery).
+		WithArgs(productId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(database.Metrics(nil)[1]))
+	before := operationSampleCount(t, registry, "delete-product-db")
+
+	err := database.DeleteProduct(db, productId, context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, before+1, operationSampleCount(t, registry, "delete-product-db"))
+}
+
+// operationSampleCount returns the number of durations observed for the operation, the histogram being shared by
+// all the tests of the package
+func operationSampleCount(t *testing.T, registry *prometheus.Registry, operation string) uint64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" && label.GetValue() == operation {
+					return metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
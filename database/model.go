@@ -1,6 +1,10 @@
 package database
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type config struct {
 	dbHost     string
@@ -12,12 +16,165 @@ type config struct {
 }
 
 type Product struct {
-	ID    int     `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Price       float64         `json:"price"`
+	Status      string          `json:"status"`
+	PublishAt   *time.Time      `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time      `json:"unpublish_at,omitempty"`
+	Category    string          `json:"category,omitempty"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+	Variants    []*Variant      `json:"variants,omitempty"`
+	// RatingAverage and RatingCount aggregate the approved reviews, they are maintained by the database
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+	// Locale, Name and Description are localised by LocalizeProducts, Locale stays empty if no translation matched
+	Locale      string `json:"locale,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Pricing is computed from the active promotions, it is never stored
+	Pricing *Pricing `json:"pricing,omitempty"`
+}
+
+// Promotion discounts the products listed in ProductIDs and the products of Categories while the current time is
+// within StartsAt and EndsAt, both optional. Value is a percentage or an amount depending on Kind.
+// Promotions apply by descending Priority, a non stackable promotion is never combined with others.
+type Promotion struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	Value      float64    `json:"value"`
+	ProductIDs []int64    `json:"product_ids,omitempty"`
+	Categories []string   `json:"categories,omitempty"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	Priority   int        `json:"priority"`
+	Stackable  bool       `json:"stackable"`
+}
+
+// ApiKey authenticates callers that cannot use bearer tokens. Only Prefix, the public part of the key, is ever shown
+// again after creation: the secret part is stored as Hash, a hash salted with Salt.
+type ApiKey struct {
+	ID         int        `json:"id"`
+	TenantID   string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Salt       []byte     `json:"-"`
+	Hash       []byte     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type Pricing struct {
+	ListPrice         float64             `json:"list_price"`
+	EffectivePrice    float64             `json:"effective_price"`
+	AppliedPromotions []*AppliedPromotion `json:"applied_promotions"`
+}
+
+type AppliedPromotion struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Discount float64 `json:"discount"`
+}
+
+type Translation struct {
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Variant is a purchasable version of a parent product, e.g. a size of a t-shirt.
+// When Price is nil the variant is sold at the parent product price.
+type Variant struct {
+	ID         int             `json:"id"`
+	ProductID  int             `json:"product_id"`
+	SKU        string          `json:"sku"`
+	Name       string          `json:"name"`
+	Price      *float64        `json:"price,omitempty"`
+	Stock      int             `json:"stock"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+// Image is the metadata of a product image, whose content is kept in a blob store under StorageKey.
+type Image struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	StorageKey  string    `json:"-"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	Position    int       `json:"position"`
+	Primary     bool      `json:"primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProductsFilter restricts the products returned by GetProducts. Empty fields do not filter.
+type ProductsFilter struct {
+	Statuses []string
+	// Attributes matches products whose attributes contain all the given key/value pairs
+	Attributes map[string]string
+	// Search matches products whose name, or translation in one of Locales, contains the given words
+	Search  string
+	Locales []string
+	// Sort orders the products by ID, unless set to ProductsSortRating
+	Sort string
+}
+
+// Review is a customer rating of a product, counted in the product rating once approved by a moderator.
+type Review struct {
+	ID          int        `json:"id"`
+	ProductID   int        `json:"product_id"`
+	Author      string     `json:"author"`
+	Rating      int        `json:"rating"`
+	Title       string     `json:"title,omitempty"`
+	Body        string     `json:"body,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"`
+}
+
+type CategorySchema struct {
+	Category string          `json:"category"`
+	Schema   json.RawMessage `json:"schema"`
+}
+
+// CatalogueStats sums up the products for the business metrics.
+type CatalogueStats struct {
+	Products     int
+	AveragePrice float64
+	MedianPrice  float64
+	// PriceBands counts the products by price band, from the prices below the first bound to the ones above the last
+	PriceBands []int
 }
 
 func (p *Product) String() string {
-	return fmt.Sprintf("ID[%d], Name[%s], Price[%f]",
-		p.ID, p.Name, p.Price)
+	return fmt.Sprintf("ID[%d], Name[%s], Price[%f], Status[%s], Category[%s]",
+		p.ID, p.Name, p.Price, p.Status, p.Category)
+}
+
+func (v *Variant) String() string {
+	return fmt.Sprintf("ID[%d], ProductID[%d], SKU[%s], Name[%s], Stock[%d]",
+		v.ID, v.ProductID, v.SKU, v.Name, v.Stock)
+}
+
+func (i *Image) String() string {
+	return fmt.Sprintf("ID[%d], ProductID[%d], FileName[%s], ContentType[%s], Size[%d], Position[%d], Primary[%t]",
+		i.ID, i.ProductID, i.FileName, i.ContentType, i.Size, i.Position, i.Primary)
+}
+
+func (p *Promotion) String() string {
+	return fmt.Sprintf("ID[%d], Name[%s], Kind[%s], Value[%f], Priority[%d], Stackable[%t]",
+		p.ID, p.Name, p.Kind, p.Value, p.Priority, p.Stackable)
+}
+
+func (k *ApiKey) String() string {
+	return fmt.Sprintf("ID[%d], Name[%s], Prefix[%s], Scopes%v", k.ID, k.Name, k.Prefix, k.Scopes)
+}
+
+func (r *Review) String() string {
+	return fmt.Sprintf("ID[%d], ProductID[%d], Author[%s], Rating[%d], Status[%s]",
+		r.ID, r.ProductID, r.Author, r.Rating, r.Status)
 }
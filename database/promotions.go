@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name missing")
+	}
+	switch p.Kind {
+	case PromotionKindPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("percentage value must be greater than 0 and at most 100")
+		}
+	case PromotionKindFixed:
+		if p.Value <= 0 {
+			return fmt.Errorf("fixed value must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("kind %q not valid", p.Kind)
+	}
+	if len(p.ProductIDs) == 0 && len(p.Categories) == 0 {
+		return fmt.Errorf("product_ids or categories missing")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func GetPromotions(db Querier, ctx context.Context) ([]*Promotion, error) {
+	span := startSpan(ctx, "get-promotions-db")
+	defer span.Finish()
+
+	span.SetTag("query", getPromotionsQuery)
+
+	rows, queryErr := db.QueryContext(ctx, getPromotionsQuery)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	promotions, scanErr := scanPromotions(rows)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	span.SetTag("promotions-found", len(promotions))
+	span.LogKV("promotions-found", len(promotions))
+
+	return promotions, nil
+}
+
+// GetActivePromotions returns the promotions whose date window contains the given time, by descending priority.
+func GetActivePromotions(db Querier, now time.Time, ctx context.Context) ([]*Promotion, error) {
+	span := startSpan(ctx, "get-active-promotions-db")
+	defer span.Finish()
+
+	span.SetTag("query", getActivePromotionsQuery)
+
+	rows, queryErr := db.QueryContext(ctx, getActivePromotionsQuery, now)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	promotions, scanErr := scanPromotions(rows)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	span.SetTag("promotions-found", len(promotions))
+	span.LogKV("promotions-found", len(promotions))
+
+	return promotions, nil
+}
+
+func GetPromotion(db Querier, promotion *Promotion, ctx context.Context) error {
+	span := startSpan(ctx, "get-promotion-db")
+	defer span.Finish()
+
+	span.SetTag("promotion-id", promotion.ID)
+	span.LogKV("promotion-id", promotion.ID)
+
+	return db.QueryRowContext(ctx, getPromotionQuery, promotion.ID).
+		Scan(&promotion.Name, &promotion.Kind, &promotion.Value, pq.Array(&promotion.ProductIDs),
+			pq.Array(&promotion.Categories), &promotion.StartsAt, &promotion.EndsAt, &promotion.Priority,
+			&promotion.Stackable)
+}
+
+func CreatePromotion(db Querier, promotion *Promotion, ctx context.Context) error {
+	span := startSpan(ctx, "create-promotion-db")
+	defer span.Finish()
+
+	span.SetTag("promotion", promotion.String())
+	span.LogKV("promotion", promotion.String())
+
+	return db.QueryRowContext(ctx, createPromotionQuery,
+		promotion.Name, promotion.Kind, promotion.Value, pq.Array(productIdsOrEmpty(promotion.ProductIDs)),
+		pq.Array(categoriesOrEmpty(promotion.Categories)), promotion.StartsAt, promotion.EndsAt, promotion.Priority,
+		promotion.Stackable).
+		Scan(&promotion.ID)
+}
+
+// UpdatePromotion returns sql.ErrNoRows if the promotion does not exist.
+func UpdatePromotion(db Querier, promotion *Promotion, ctx context.Context) error {
+	span := startSpan(ctx, "update-promotion-db")
+	defer span.Finish()
+
+	span.SetTag("promotion", promotion.String())
+	span.LogKV("promotion", promotion.String())
+
+	result, err := db.ExecContext(ctx, updatePromotionQuery,
+		promotion.Name, promotion.Kind, promotion.Value, pq.Array(productIdsOrEmpty(promotion.ProductIDs)),
+		pq.Array(categoriesOrEmpty(promotion.Categories)), promotion.StartsAt, promotion.EndsAt, promotion.Priority,
+		promotion.Stackable, promotion.ID)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
+
+// DeletePromotion returns sql.ErrNoRows if the promotion does not exist.
+func DeletePromotion(db Querier, promotionId int, ctx context.Context) error {
+	span := startSpan(ctx, "delete-promotion-db")
+	defer span.Finish()
+
+	span.SetTag("promotion-id", promotionId)
+	span.LogKV("promotion-id", promotionId)
+
+	result, err := db.ExecContext(ctx, deletePromotionQuery, promotionId)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
+
+func scanPromotions(rows *sql.Rows) ([]*Promotion, error) {
+	promotions := make([]*Promotion, 0)
+	for rows.Next() {
+		var promotion Promotion
+		rowErr := rows.Scan(&promotion.ID, &promotion.Name, &promotion.Kind, &promotion.Value,
+			pq.Array(&promotion.ProductIDs), pq.Array(&promotion.Categories), &promotion.StartsAt, &promotion.EndsAt,
+			&promotion.Priority, &promotion.Stackable)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		promotions = append(promotions, &promotion)
+	}
+	return promotions, nil
+}
+
+// productIdsOrEmpty and categoriesOrEmpty avoid sending NULL arrays to the NOT NULL columns.
+func productIdsOrEmpty(productIds []int64) []int64 {
+	if productIds == nil {
+		return []int64{}
+	}
+	return productIds
+}
+
+func categoriesOrEmpty(categories []string) []string {
+	if categories == nil {
+		return []string{}
+	}
+	return categories
+}
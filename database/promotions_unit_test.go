@@ -0,0 +1,106 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	promotionId   = 5
+	promotionName = "summer-sale"
+)
+
+func TestPromotionValidate_Unit_Success(t *testing.T) {
+	promotion := &database.Promotion{Name: promotionName, Kind: database.PromotionKindPercentage, Value: 20,
+		Categories: []string{productCategory}}
+
+	assert.NoError(t, promotion.Validate())
+}
+
+func TestPromotionValidate_Unit_Fail(t *testing.T) {
+	start := time.Now()
+	end := start.Add(-time.Hour)
+	products := []int64{productId}
+
+	assert.Error(t, (&database.Promotion{Kind: database.PromotionKindFixed, Value: 5, ProductIDs: products}).Validate())
+	assert.Error(t, (&database.Promotion{Name: promotionName, Kind: "bogo", Value: 5, ProductIDs: products}).Validate())
+	assert.Error(t, (&database.Promotion{Name: promotionName, Kind: database.PromotionKindPercentage, Value: 120, ProductIDs: products}).Validate())
+	assert.Error(t, (&database.Promotion{Name: promotionName, Kind: database.PromotionKindFixed, Value: 0, ProductIDs: products}).Validate())
+	assert.Error(t, (&database.Promotion{Name: promotionName, Kind: database.PromotionKindFixed, Value: 5}).Validate())
+	assert.Error(t, (&database.Promotion{Name: promotionName, Kind: database.PromotionKindFixed, Value: 5, ProductIDs: products,
+		StartsAt: &start, EndsAt: &end}).Validate())
+}
+
+func TestGetActivePromotions_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "kind", "value", "product_ids", "categories", "starts_at", "ends_at", "priority", "stackable"}).
+		AddRow(promotionId, promotionName, database.PromotionKindPercentage, "20.00", "{42,43}", "{t-shirts}", nil, nil, 10, true)
+
+	mock.ExpectQuery(getActivePromotionsQuery).
+		WithArgs(now).
+		WillReturnRows(rows)
+
+	promotions, err := database.GetActivePromotions(db, now, context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, promotions, 1)
+	assert.Equal(t, 20.0, promotions[0].Value)
+	assert.Equal(t, []int64{productId, productId2}, promotions[0].ProductIDs)
+	assert.Equal(t, []string{productCategory}, promotions[0].Categories)
+	assert.True(t, promotions[0].Stackable)
+}
+
+func TestCreatePromotion_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(promotionId)
+
+	mock.ExpectQuery(createPromotionQuery).
+		WithArgs(promotionName, database.PromotionKindFixed, 5.0, "{}", "{\"t-shirts\"}", nil, nil, 0, false).
+		WillReturnRows(rows)
+
+	promotion := &database.Promotion{Name: promotionName, Kind: database.PromotionKindFixed, Value: 5,
+		Categories: []string{productCategory}}
+	err := database.CreatePromotion(db, promotion, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, promotionId, promotion.ID)
+}
+
+func TestDeletePromotion_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deletePromotionQuery).
+		WithArgs(promotionId).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := database.DeletePromotion(db, promotionId, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
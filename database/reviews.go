@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+func IsValidReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Review) Validate() error {
+	if r.Author == "" {
+		return fmt.Errorf("author missing")
+	}
+	if r.Rating < 1 || r.Rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	return nil
+}
+
+// GetReviews returns the reviews of the product in the given statuses, newest first.
+func GetReviews(db Querier, productId, start, count int, statuses []string, ctx context.Context) ([]*Review, error) {
+	span := startSpan(ctx, "get-reviews-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.SetTag("statuses", strings.Join(statuses, ","))
+	span.LogKV("product-id", productId, "statuses", strings.Join(statuses, ","))
+
+	rows, queryErr := db.QueryContext(ctx, getReviewsQuery, productId, pq.Array(statuses), count, start)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	reviews := make([]*Review, 0)
+	for rows.Next() {
+		review := Review{ProductID: productId}
+		rowErr := rows.Scan(&review.ID, &review.Author, &review.Rating, &review.Title, &review.Body, &review.Status,
+			&review.CreatedAt, &review.ModeratedAt)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		reviews = append(reviews, &review)
+	}
+
+	span.SetTag("reviews-found", len(reviews))
+	span.LogKV("reviews-found", len(reviews))
+
+	return reviews, nil
+}
+
+func GetReview(db Querier, review *Review, ctx context.Context) error {
+	span := startSpan(ctx, "get-review-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", review.ProductID)
+	span.SetTag("review-id", review.ID)
+	span.LogKV("product-id", review.ProductID, "review-id", review.ID)
+
+	return db.QueryRowContext(ctx, getReviewQuery, review.ID, review.ProductID).
+		Scan(&review.Author, &review.Rating, &review.Title, &review.Body, &review.Status,
+			&review.CreatedAt, &review.ModeratedAt)
+}
+
+// CreateReview stores the review as pending, it does not count in the product rating until approved.
+func CreateReview(db Querier, review *Review, ctx context.Context) error {
+	span := startSpan(ctx, "create-review-db")
+	defer span.Finish()
+
+	span.SetTag("review", review.String())
+	span.LogKV("review", review.String())
+
+	return db.QueryRowContext(ctx, createReviewQuery,
+		review.ProductID, review.Author, review.Rating, review.Title, review.Body).
+		Scan(&review.ID, &review.Status, &review.CreatedAt)
+}
+
+// ModerateReview sets the status of the review, the product rating is updated accordingly by the database.
+// It returns sql.ErrNoRows if the review does not exist under the given product.
+func ModerateReview(db Querier, review *Review, ctx context.Context) error {
+	span := startSpan(ctx, "moderate-review-db")
+	defer span.Finish()
+
+	span.SetTag("review", review.String())
+	span.LogKV("review", review.String())
+
+	return db.QueryRowContext(ctx, moderateReviewQuery, review.Status, review.ID, review.ProductID).
+		Scan(&review.ModeratedAt)
+}
+
+// DeleteReview returns sql.ErrNoRows if the review does not exist under the given product.
+func DeleteReview(db Querier, productId, reviewId int, ctx context.Context) error {
+	span := startSpan(ctx, "delete-review-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.SetTag("review-id", reviewId)
+	span.LogKV("product-id", productId, "review-id", reviewId)
+
+	result, err := db.ExecContext(ctx, deleteReviewQuery, reviewId, productId)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
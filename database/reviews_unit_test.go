@@ -0,0 +1,95 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	reviewId     = 11
+	reviewAuthor = "jane"
+	reviewRating = 4
+	reviewTitle  = "nice fabric"
+)
+
+func TestReviewValidate_Unit(t *testing.T) {
+	assert.NoError(t, (&database.Review{Author: reviewAuthor, Rating: reviewRating}).Validate())
+	assert.Error(t, (&database.Review{Rating: reviewRating}).Validate())
+	assert.Error(t, (&database.Review{Author: reviewAuthor, Rating: 0}).Validate())
+	assert.Error(t, (&database.Review{Author: reviewAuthor, Rating: 6}).Validate())
+}
+
+func TestGetReviews_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "author", "rating", "title", "body", "status", "created_at", "moderated_at"}).
+		AddRow(reviewId, reviewAuthor, reviewRating, reviewTitle, "", database.ReviewStatusApproved, now, now)
+
+	mock.ExpectQuery(getReviewsQuery).
+		WithArgs(productId, pq.Array([]string{database.ReviewStatusApproved}), 10, 0).
+		WillReturnRows(rows)
+
+	reviews, err := database.GetReviews(db, productId, 0, 10, []string{database.ReviewStatusApproved}, context.Background())
+
+	assert.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, productId, reviews[0].ProductID)
+	assert.Equal(t, reviewRating, reviews[0].Rating)
+	require.NotNil(t, reviews[0].ModeratedAt)
+}
+
+func TestCreateReview_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "status", "created_at"}).
+		AddRow(reviewId, database.ReviewStatusPending, time.Now())
+
+	mock.ExpectQuery(createReviewQuery).
+		WithArgs(productId, reviewAuthor, reviewRating, reviewTitle, "").
+		WillReturnRows(rows)
+
+	review := &database.Review{ProductID: productId, Author: reviewAuthor, Rating: reviewRating, Title: reviewTitle}
+	err := database.CreateReview(db, review, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, reviewId, review.ID)
+	assert.Equal(t, database.ReviewStatusPending, review.Status)
+}
+
+func TestModerateReview_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectQuery(moderateReviewQuery).
+		WithArgs(database.ReviewStatusApproved, reviewId, productId).
+		WillReturnRows(sqlmock.NewRows([]string{"moderated_at"}))
+
+	review := &database.Review{ID: reviewId, ProductID: productId, Status: database.ReviewStatusApproved}
+	err := database.ModerateReview(db, review, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
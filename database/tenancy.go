@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+var (
+	tenantIdRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+	// tenantTables are isolated by row level security
+	tenantTables = []string{
+		"products",
+		"category_schemas",
+		"product_variants",
+		"product_translations",
+		"product_images",
+		"promotions",
+		"product_reviews",
+		"api_keys",
+	}
+	// tenantProductTables reference products
+	tenantProductTables = []string{
+		"product_variants",
+		"product_translations",
+		"product_images",
+		"product_reviews",
+	}
+)
+
+// Querier is implemented by both *sql.DB and *sql.Tx, so that the same functions run inside or outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func ValidateTenantId(tenantId string) error {
+	if !tenantIdRegexp.MatchString(tenantId) {
+		return fmt.Errorf("tenant %q not valid", tenantId)
+	}
+	return nil
+}
+
+// BeginTenantTx starts a transaction that only sees and writes the rows of the tenant, whatever the queries run in it.
+// The transaction switches to a role subject to row level security, so isolation holds even for superusers.
+func BeginTenantTx(db *sql.DB, tenantId string, ctx context.Context) (*sql.Tx, error) {
+	span := startSpan(ctx, "begin-tenant-tx-db")
+	defer span.Finish()
+
+	span.SetTag("tenant", tenantId)
+	span.LogKV("tenant", tenantId)
+
+	validateErr := ValidateTenantId(tenantId)
+	if validateErr != nil {
+		return nil, validateErr
+	}
+
+	tx, txErr := db.BeginTx(ctx, nil)
+	if txErr != nil {
+		return nil, txErr
+	}
+	_, roleErr := tx.ExecContext(ctx, setTenantRoleQuery)
+	if roleErr != nil {
+		tx.Rollback()
+		return nil, roleErr
+	}
+	_, tenantErr := tx.ExecContext(ctx, setTenantQuery, tenantId)
+	if tenantErr != nil {
+		tx.Rollback()
+		return nil, tenantErr
+	}
+	return tx, nil
+}
+
+// tenancyQueries returns the migrations adding tenant_id to every table and the row level security policies on it.
+func tenancyQueries() []string {
+	queries := []string{createTenantRoleQuery, grantTenantRoleQuery}
+	for _, table := range tenantTables {
+		queries = append(queries,
+			fmt.Sprintf(addTenantColumnQueryFormat, table),
+			fmt.Sprintf(setTenantColumnDefaultQueryFormat, table),
+		)
+	}
+
+	queries = append(queries, createProductsTenantKeyQuery)
+	for _, table := range tenantProductTables {
+		queries = append(queries, fmt.Sprintf(replaceConstraintQueryFormat,
+			table, table+"_product_fkey", table+"_tenant_product_fkey", tenantProductForeignKey))
+	}
+	queries = append(queries,
+		fmt.Sprintf(replaceConstraintQueryFormat,
+			"category_schemas", "category_schemas_pkey", "category_schemas_tenant_pkey", "PRIMARY KEY (tenant_id, category)"),
+		fmt.Sprintf(replaceConstraintQueryFormat,
+			"product_variants", "product_variants_sku_key", "product_variants_tenant_sku_key", "UNIQUE (tenant_id, sku)"),
+	)
+
+	for _, table := range tenantTables {
+		queries = append(queries,
+			fmt.Sprintf(enableRowLevelSecurityQuery, table),
+			fmt.Sprintf(dropTenantPolicyQuery, table),
+			fmt.Sprintf(createTenantPolicyQuery, table),
+		)
+	}
+	return append(queries, grantTenantTablesQuery, grantTenantSequencesQuery)
+}
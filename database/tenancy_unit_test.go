@@ -0,0 +1,79 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const tenantId = "acme"
+
+func TestValidateTenantId_Unit(t *testing.T) {
+	assert.NoError(t, database.ValidateTenantId(tenantId))
+	assert.NoError(t, database.ValidateTenantId("acme-eu_2"))
+	assert.Error(t, database.ValidateTenantId(""))
+	assert.Error(t, database.ValidateTenantId("Acme"))
+	assert.Error(t, database.ValidateTenantId("-acme"))
+	assert.Error(t, database.ValidateTenantId("acme'; --"))
+}
+
+func TestBeginTenantTx_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(setTenantRoleQuery).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(setTenantQuery).
+		WithArgs(tenantId).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, err := database.BeginTenantTx(db, tenantId, context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBeginTenantTx_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(setTenantRoleQuery).
+		WillReturnError(fmt.Errorf("error"))
+	mock.ExpectRollback()
+
+	_, err := database.BeginTenantTx(db, tenantId, context.Background())
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBeginTenantTx_Unit_InvalidTenant(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	_, err := database.BeginTenantTx(db, "", context.Background())
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
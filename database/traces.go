@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/tracing"
+)
+
+// timedSpan observes the duration of the DB operation it traces when finished, with its trace as exemplar.
+type timedSpan struct {
+	opentracing.Span
+	operationName string
+	start         time.Time
+}
+
+func (s *timedSpan) Finish() {
+	tracing.ObserveWithTraceID(queryDuration.WithLabelValues(s.operationName), time.Since(s.start).Seconds(),
+		tracing.SampledTraceID(s.Span.Context()))
+	s.Span.Finish()
+}
+
+func startSpan(ctx context.Context, operationName string) opentracing.Span {
+	parentSpan := opentracing.SpanFromContext(ctx)
+	var parentCtx opentracing.SpanContext
+	if parentSpan != nil {
+		parentCtx = parentSpan.Context()
+	}
+	span := opentracing.StartSpan(
+		operationName,
+		opentracing.ChildOf(parentCtx),
+	)
+	if requestId := logging.RequestId(ctx); requestId != "" {
+		span.SetTag("request-id", requestId)
+	}
+	return &timedSpan{Span: span, operationName: operationName, start: time.Now()}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const defaultSearchConfig = "simple"
+
+var (
+	localeRegexp = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+	// searchConfigs maps languages to the PostgreSQL text search configurations shipped by default
+	searchConfigs = map[string]string{
+		"da": "danish",
+		"de": "german",
+		"en": "english",
+		"es": "spanish",
+		"fi": "finnish",
+		"fr": "french",
+		"hu": "hungarian",
+		"it": "italian",
+		"nl": "dutch",
+		"no": "norwegian",
+		"pt": "portuguese",
+		"ro": "romanian",
+		"ru": "russian",
+		"sv": "swedish",
+		"tr": "turkish",
+	}
+)
+
+// NormalizeLocale validates a BCP 47 like language tag and returns its canonical casing, e.g. "de-CH".
+func NormalizeLocale(locale string) (string, error) {
+	if !localeRegexp.MatchString(locale) {
+		return "", fmt.Errorf("locale %q not valid", locale)
+	}
+	subtags := strings.Split(locale, "-")
+	subtags[0] = strings.ToLower(subtags[0])
+	for i := 1; i < len(subtags); i++ {
+		if len(subtags[i]) == 2 {
+			subtags[i] = strings.ToUpper(subtags[i])
+		}
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// SearchConfigForLocale returns the full-text search configuration of the locale language, "simple" if unknown.
+func SearchConfigForLocale(locale string) string {
+	language := strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+	if config, ok := searchConfigs[language]; ok {
+		return config
+	}
+	return defaultSearchConfig
+}
+
+// SelectTranslation returns the translation matching the first possible locale, nil if none matches.
+func SelectTranslation(translations []*Translation, locales []string) *Translation {
+	for _, locale := range locales {
+		for _, translation := range translations {
+			if translation.Locale == locale {
+				return translation
+			}
+		}
+	}
+	return nil
+}
+
+// LocalizeProducts replaces name and description of the products with the best translation among the locales,
+// given in order of preference. Products without a matching translation keep their original name.
+func LocalizeProducts(db Querier, products []*Product, locales []string, ctx context.Context) error {
+	if len(products) == 0 || len(locales) == 0 {
+		return nil
+	}
+
+	span := startSpan(ctx, "localize-products-db")
+	defer span.Finish()
+
+	productIds := make([]int64, 0, len(products))
+	for _, product := range products {
+		productIds = append(productIds, int64(product.ID))
+	}
+
+	span.SetTag("products", len(products))
+	span.SetTag("locales", strings.Join(locales, ","))
+	span.LogKV("products", len(products), "locales", strings.Join(locales, ","))
+
+	rows, queryErr := db.QueryContext(ctx, getProductsTranslationsQuery, pq.Array(productIds), pq.Array(locales))
+	if queryErr != nil {
+		return queryErr
+	}
+	defer rows.Close()
+
+	translations := make(map[int][]*Translation)
+	for rows.Next() {
+		var productId int
+		var translation Translation
+		rowErr := rows.Scan(&productId, &translation.Locale, &translation.Name, &translation.Description)
+		if rowErr != nil {
+			return rowErr
+		}
+		translations[productId] = append(translations[productId], &translation)
+	}
+
+	localized := 0
+	for _, product := range products {
+		translation := SelectTranslation(translations[product.ID], locales)
+		if translation == nil {
+			continue
+		}
+		product.Locale = translation.Locale
+		product.Name = translation.Name
+		product.Description = translation.Description
+		localized++
+	}
+
+	span.SetTag("products-localized", localized)
+	span.LogKV("products-localized", localized)
+
+	return nil
+}
+
+func GetTranslations(db Querier, productId int, ctx context.Context) ([]*Translation, error) {
+	span := startSpan(ctx, "get-translations-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.LogKV("product-id", productId)
+
+	rows, queryErr := db.QueryContext(ctx, getTranslationsQuery, productId)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	translations := make([]*Translation, 0)
+	for rows.Next() {
+		var translation Translation
+		rowErr := rows.Scan(&translation.Locale, &translation.Name, &translation.Description)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		translations = append(translations, &translation)
+	}
+
+	span.SetTag("translations-found", len(translations))
+	span.LogKV("translations-found", len(translations))
+
+	return translations, nil
+}
+
+func UpsertTranslation(db Querier, productId int, translation *Translation, ctx context.Context) error {
+	span := startSpan(ctx, "upsert-translation-db")
+	defer span.Finish()
+
+	searchConfig := SearchConfigForLocale(translation.Locale)
+
+	span.SetTag("product-id", productId)
+	span.SetTag("locale", translation.Locale)
+	span.SetTag("search-config", searchConfig)
+	span.LogKV("product-id", productId, "locale", translation.Locale, "search-config", searchConfig)
+
+	_, err := db.ExecContext(ctx, upsertTranslationQuery,
+		productId, translation.Locale, translation.Name, translation.Description, searchConfig)
+	return err
+}
+
+// DeleteTranslation returns sql.ErrNoRows if the product has no translation for the locale.
+func DeleteTranslation(db Querier, productId int, locale string, ctx context.Context) error {
+	span := startSpan(ctx, "delete-translation-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.SetTag("locale", locale)
+	span.LogKV("product-id", productId, "locale", locale)
+
+	result, err := db.ExecContext(ctx, deleteTranslationQuery, productId, locale)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
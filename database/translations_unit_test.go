@@ -0,0 +1,109 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestNormalizeLocale_Unit_Success(t *testing.T) {
+	locale, err := database.NormalizeLocale("DE-ch")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "de-CH", locale)
+}
+
+func TestNormalizeLocale_Unit_Fail(t *testing.T) {
+	_, err := database.NormalizeLocale("de_CH")
+
+	assert.Error(t, err)
+}
+
+func TestSearchConfigForLocale_Unit(t *testing.T) {
+	assert.Equal(t, "german", database.SearchConfigForLocale("de-CH"))
+	assert.Equal(t, "english", database.SearchConfigForLocale("en"))
+	assert.Equal(t, "simple", database.SearchConfigForLocale("ja"))
+}
+
+func TestSelectTranslation_Unit(t *testing.T) {
+	translations := []*database.Translation{
+		{Locale: "de", Name: "Hemd"},
+		{Locale: "fr", Name: "Chemise"},
+	}
+
+	assert.Equal(t, "Hemd", database.SelectTranslation(translations, []string{"de-CH", "de", "fr"}).Name)
+	assert.Equal(t, "Chemise", database.SelectTranslation(translations, []string{"fr", "de"}).Name)
+	assert.Nil(t, database.SelectTranslation(translations, []string{"it", "en"}))
+}
+
+func TestLocalizeProducts_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"product_id", "locale", "name", "description"}).
+		AddRow(productId, "en", "Shirt", "A shirt").
+		AddRow(productId, "de", "Hemd", "Ein Hemd")
+
+	locales := []string{"de-CH", "de", "en"}
+	mock.ExpectQuery(getProductsTranslationsQuery).
+		WithArgs(pq.Array([]int64{productId, productId2}), pq.Array(locales)).
+		WillReturnRows(rows)
+
+	products := []*database.Product{
+		{ID: productId, Name: productName},
+		{ID: productId2, Name: productName2},
+	}
+	err := database.LocalizeProducts(db, products, locales, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "de", products[0].Locale)
+	assert.Equal(t, "Hemd", products[0].Name)
+	assert.Equal(t, "Ein Hemd", products[0].Description)
+	assert.Equal(t, "", products[1].Locale)
+	assert.Equal(t, productName2, products[1].Name)
+}
+
+func TestUpsertTranslation_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(upsertTranslationQuery).
+		WithArgs(productId, "fr", "Chemise", "", "french").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	translation := &database.Translation{Locale: "fr", Name: "Chemise"}
+	err := database.UpsertTranslation(db, productId, translation, context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestDeleteTranslation_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deleteTranslationQuery).
+		WithArgs(productId, "fr").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := database.DeleteTranslation(db, productId, "fr", context.Background())
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+func (v *Variant) Validate() error {
+	if v.SKU == "" {
+		return fmt.Errorf("sku missing")
+	}
+	if v.Stock < 0 {
+		return fmt.Errorf("stock must not be negative")
+	}
+	if v.Price != nil && *v.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	return nil
+}
+
+func GetVariants(db Querier, productId int, ctx context.Context) ([]*Variant, error) {
+	span := startSpan(ctx, "get-variants-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.LogKV("product-id", productId)
+
+	rows, queryErr := db.QueryContext(ctx, getVariantsQuery, productId)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	defer rows.Close()
+
+	variants := make([]*Variant, 0)
+	for rows.Next() {
+		variant := Variant{ProductID: productId}
+		var attributes []byte
+		rowErr := rows.Scan(&variant.ID, &variant.SKU, &variant.Name, &variant.Price, &variant.Stock, &attributes)
+		if rowErr != nil {
+			return nil, rowErr
+		}
+		variant.Attributes = attributes
+		variants = append(variants, &variant)
+	}
+
+	span.SetTag("variants-found", len(variants))
+	span.LogKV("variants-found", len(variants))
+
+	return variants, nil
+}
+
+func GetVariant(db Querier, variant *Variant, ctx context.Context) error {
+	return getVariant(db, getVariantQuery, "get-variant-db", variant, ctx)
+}
+
+// GetVariantForUpdate reads the variant like GetVariant and locks it until the end of the transaction, so that
+// concurrent updates checked against the same current version cannot both be applied.
+func GetVariantForUpdate(db Querier, variant *Variant, ctx context.Context) error {
+	return getVariant(db, getVariantForUpdateQuery, "get-variant-for-update-db", variant, ctx)
+}
+
+func getVariant(db Querier, query, operationName string, variant *Variant, ctx context.Context) error {
+	span := startSpan(ctx, operationName)
+	defer span.Finish()
+
+	span.SetTag("product-id", variant.ProductID)
+	span.SetTag("variant-id", variant.ID)
+	span.LogKV("product-id", variant.ProductID, "variant-id", variant.ID)
+
+	var attributes []byte
+	err := db.QueryRowContext(ctx, query, variant.ID, variant.ProductID).
+		Scan(&variant.SKU, &variant.Name, &variant.Price, &variant.Stock, &attributes)
+	if err != nil {
+		return err
+	}
+	variant.Attributes = attributes
+	return nil
+}
+
+func CreateVariant(db Querier, variant *Variant, ctx context.Context) error {
+	span := startSpan(ctx, "create-variant-db")
+	defer span.Finish()
+
+	span.SetTag("variant", variant.String())
+	span.LogKV("variant", variant.String())
+
+	return db.QueryRowContext(ctx, createVariantQuery,
+		variant.ProductID, variant.SKU, variant.Name, variant.Price, variant.Stock, attributesOrEmpty(variant.Attributes)).
+		Scan(&variant.ID)
+}
+
+// UpdateVariant returns sql.ErrNoRows if the variant does not exist under the given product.
+func UpdateVariant(db Querier, variant *Variant, ctx context.Context) error {
+	span := startSpan(ctx, "update-variant-db")
+	defer span.Finish()
+
+	span.SetTag("variant", variant.String())
+	span.LogKV("variant", variant.String())
+
+	result, err := db.ExecContext(ctx, updateVariantQuery,
+		variant.SKU, variant.Name, variant.Price, variant.Stock, attributesOrEmpty(variant.Attributes),
+		variant.ID, variant.ProductID)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
+
+// DeleteVariant returns sql.ErrNoRows if the variant does not exist under the given product.
+func DeleteVariant(db Querier, productId, variantId int, ctx context.Context) error {
+	span := startSpan(ctx, "delete-variant-db")
+	defer span.Finish()
+
+	span.SetTag("product-id", productId)
+	span.SetTag("variant-id", variantId)
+	span.LogKV("product-id", productId, "variant-id", variantId)
+
+	result, err := db.ExecContext(ctx, deleteVariantQuery, variantId, productId)
+	if err != nil {
+		return err
+	}
+	return expectAffectedRows(result)
+}
+
+func expectAffectedRows(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
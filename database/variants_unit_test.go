@@ -0,0 +1,152 @@
+// +build !integration
+
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func TestVariantValidate_Unit_Success(t *testing.T) {
+	price := 19.90
+	variant := &database.Variant{SKU: variantSKU, Stock: variantStock, Price: &price}
+
+	assert.NoError(t, variant.Validate())
+}
+
+func TestVariantValidate_Unit_Fail(t *testing.T) {
+	price := -1.0
+
+	assert.Error(t, (&database.Variant{Stock: variantStock}).Validate())
+	assert.Error(t, (&database.Variant{SKU: variantSKU, Stock: -1}).Validate())
+	assert.Error(t, (&database.Variant{SKU: variantSKU, Price: &price}).Validate())
+}
+
+func TestGetVariants_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "sku", "name", "price", "stock", "attributes"}).
+		AddRow(variantId, variantSKU, variantName, nil, variantStock, []byte(`{"size":"M"}`)).
+		AddRow(variantId+1, variantSKU+"-2", variantName, "21.50", variantStock, []byte(`{}`))
+
+	mock.ExpectQuery(getVariantsQuery).
+		WithArgs(productId).
+		WillReturnRows(rows)
+
+	variants, err := database.GetVariants(db, productId, context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, productId, variants[0].ProductID)
+	assert.Equal(t, variantSKU, variants[0].SKU)
+	assert.Nil(t, variants[0].Price)
+	require.NotNil(t, variants[1].Price)
+	assert.Equal(t, 21.50, *variants[1].Price)
+}
+
+func TestGetVariantForUpdate_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewEqualMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"sku", "name", "price", "stock", "attributes"}).
+		AddRow(variantSKU, variantName, "21.50", variantStock, []byte(`{}`))
+
+	mock.ExpectQuery("SELECT sku,name,price,stock,attributes FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE").
+		WithArgs(variantId, productId).
+		WillReturnRows(rows)
+
+	variant := &database.Variant{ID: variantId, ProductID: productId}
+	err := database.GetVariantForUpdate(db, variant, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, variantSKU, variant.SKU)
+	require.NotNil(t, variant.Price)
+	assert.Equal(t, 21.50, *variant.Price)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateVariant_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id"}).
+		AddRow(variantId)
+
+	mock.ExpectQuery(createVariantQuery).
+		WithArgs(productId, variantSKU, variantName, nil, variantStock, "{}").
+		WillReturnRows(rows)
+
+	variant := &database.Variant{ProductID: productId, SKU: variantSKU, Name: variantName, Stock: variantStock}
+	err := database.CreateVariant(db, variant, context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, variantId, variant.ID)
+}
+
+func TestUpdateVariant_Unit_NotFound(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(updateVariantQuery).
+		WithArgs(variantSKU, variantName, nil, variantStock, "{}", variantId, productId).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	variant := &database.Variant{ID: variantId, ProductID: productId, SKU: variantSKU, Name: variantName, Stock: variantStock}
+	err := database.UpdateVariant(db, variant, context.Background())
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestDeleteVariant_Unit_Success(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deleteVariantQuery).
+		WithArgs(variantId, productId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := database.DeleteVariant(db, productId, variantId, context.Background())
+
+	assert.NoError(t, err)
+}
+
+func TestDeleteVariant_Unit_Fail(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	db, mock := NewRegexpMock(t)
+	defer db.Close()
+
+	mock.ExpectExec(deleteVariantQuery).
+		WithArgs(variantId, productId).
+		WillReturnError(fmt.Errorf("error"))
+
+	err := database.DeleteVariant(db, productId, variantId, context.Background())
+
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ require (
 	github.com/HdrHistogram/hdrhistogram-go v1.1.0 // indirect
 	github.com/cenkalti/backoff v2.2.1+incompatible
 	github.com/docker/go-connections v0.4.0
+	github.com/golang-jwt/jwt/v4 v4.5.0
 	github.com/google/martian v2.1.0+incompatible // indirect
 	github.com/googleapis/gax-go v1.0.3 // indirect
 	github.com/gorilla/mux v1.8.0
@@ -21,5 +22,7 @@ require (
 	github.com/testcontainers/testcontainers-go v0.10.0
 	github.com/uber/jaeger-client-go v2.27.0+incompatible
 	github.com/uber/jaeger-lib v2.4.1+incompatible
+	github.com/xeipuuv/gojsonschema v1.2.0
 	go.uber.org/zap v1.16.0
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 )
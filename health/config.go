@@ -0,0 +1,24 @@
+package health
+
+import (
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	checkTimeoutEnvVar = "HEALTH_CHECK_TIMEOUT" // duration, time given to each check
+	drainDelayEnvVar   = "HEALTH_DRAIN_DELAY"   // duration, time given to load balancers to stop sending traffic
+
+	checkTimeoutDefault = 2 * time.Second
+	drainDelayDefault   = 5 * time.Second
+)
+
+func loadConfig() *config {
+	logging.Log.Debug("Load health configurations")
+	return &config{
+		checkTimeout: utils.GetDurationEnv(checkTimeoutEnvVar, checkTimeoutDefault),
+		drainDelay:   utils.GetDurationEnv(drainDelayEnvVar, drainDelayDefault),
+	}
+}
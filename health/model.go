@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOk       = "ok"
+	StatusDegraded = "degraded"
+	StatusFailed   = "failed"
+)
+
+// Probe is the kind of question a check answers, as asked by Kubernetes.
+type Probe string
+
+const (
+	// Liveness fails when the process must be restarted
+	Liveness Probe = "liveness"
+	// Readiness fails when the process must not receive traffic
+	Readiness Probe = "readiness"
+	// Startup fails until the process finished starting
+	Startup Probe = "startup"
+)
+
+// Check returns an error if the resource it checks is not healthy, it must stop once the context is done.
+type Check func(ctx context.Context) error
+
+type config struct {
+	checkTimeout time.Duration
+	drainDelay   time.Duration
+}
+
+// Registry holds the checks answering the probes, and the state of the process: started and draining.
+type Registry struct {
+	config *config
+	mutex  sync.RWMutex
+	checks []*registeredCheck
+	// started and draining are accessed atomically, 1 meaning true
+	started  int32
+	draining int32
+}
+
+type registeredCheck struct {
+	name  string
+	check Check
+	// critical checks fail their probes, the other ones only degrade them
+	critical bool
+	probes   []Probe
+}
+
+// Report is the outcome of a probe.
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Critical bool   `json:"critical"`
+	// Duration in milliseconds
+	Duration int64 `json:"duration_ms"`
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	startingCheckName = "started"
+	drainingCheckName = "draining"
+)
+
+func New() *Registry {
+	logging.Log.Info("Create new health registry")
+
+	return &Registry{
+		config: loadConfig(),
+	}
+}
+
+// Register adds a check answering the given probes. A failing critical check fails the probe, a failing
+// non critical one only reports it degraded, e.g. for dependencies the service can work without.
+func (r *Registry) Register(name string, critical bool, check Check, probes ...Probe) {
+	logging.SugaredLog.Debugf("Register health check %s for probes %v", name, probes)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checks = append(r.checks, &registeredCheck{name: name, check: check, critical: critical, probes: probes})
+}
+
+// MarkStarted tells the startup and readiness probes that the process finished starting.
+func (r *Registry) MarkStarted() {
+	atomic.StoreInt32(&r.started, 1)
+}
+
+// Drain fails the readiness probe, then waits the drain delay for load balancers to stop sending traffic before
+// the servers are shut down.
+func (r *Registry) Drain() {
+	if !atomic.CompareAndSwapInt32(&r.draining, 0, 1) {
+		return
+	}
+	logging.SugaredLog.Warnf("Draining traffic for %s", r.config.drainDelay)
+	time.Sleep(r.config.drainDelay)
+}
+
+func (r *Registry) Started() bool {
+	return atomic.LoadInt32(&r.started) == 1
+}
+
+func (r *Registry) Draining() bool {
+	return atomic.LoadInt32(&r.draining) == 1
+}
+
+// Run runs concurrently the checks answering the probe, each bounded by the check timeout.
+func (r *Registry) Run(ctx context.Context, probe Probe) *Report {
+	report := &Report{Status: StatusOk, Checks: map[string]*CheckResult{}}
+	if probe == Startup || probe == Readiness {
+		report.add(startingCheckName, stateResult(r.Started(), "process still starting"))
+	}
+	if probe == Readiness {
+		report.add(drainingCheckName, stateResult(!r.Draining(), "process shutting down"))
+	}
+
+	checks := r.checksOf(probe)
+	results := make([]*CheckResult, len(checks))
+	var waitGroup sync.WaitGroup
+	for index, check := range checks {
+		waitGroup.Add(1)
+		go func(index int, check *registeredCheck) {
+			defer waitGroup.Done()
+			results[index] = r.runCheck(ctx, check)
+		}(index, check)
+	}
+	waitGroup.Wait()
+
+	for index, check := range checks {
+		report.add(check.name, results[index])
+	}
+	return report
+}
+
+func (r *Registry) checksOf(probe Probe) []*registeredCheck {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	checks := make([]*registeredCheck, 0, len(r.checks))
+	for _, check := range r.checks {
+		for _, checkProbe := range check.probes {
+			if checkProbe == probe {
+				checks = append(checks, check)
+				break
+			}
+		}
+	}
+	return checks
+}
+
+func (r *Registry) runCheck(ctx context.Context, check *registeredCheck) (result *CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result = &CheckResult{Status: StatusOk, Critical: check.critical}
+	defer func() {
+		// a broken check must not break the probe endpoint with it
+		if recovered := recover(); recovered != nil {
+			result.Status = StatusFailed
+			result.Error = fmt.Sprintf("check panicked: %v", recovered)
+		}
+		result.Duration = time.Since(start).Milliseconds()
+	}()
+
+	err := check.check(ctx)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Healthy tells whether the probe succeeds, degraded reports included.
+func (r *Report) Healthy() bool {
+	return r.Status != StatusFailed
+}
+
+func (r *Report) add(name string, result *CheckResult) {
+	r.Checks[name] = result
+	if result.Status != StatusFailed {
+		return
+	}
+	if result.Critical {
+		r.Status = StatusFailed
+	} else if r.Status == StatusOk {
+		r.Status = StatusDegraded
+	}
+}
+
+func stateResult(ok bool, reason string) *CheckResult {
+	if ok {
+		return &CheckResult{Status: StatusOk, Critical: true}
+	}
+	return &CheckResult{Status: StatusFailed, Error: reason, Critical: true}
+}
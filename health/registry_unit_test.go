@@ -0,0 +1,129 @@
+// +build !integration
+
+package health_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/health"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func newRegistry(t *testing.T) *health.Registry {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	require.NoError(t, os.Setenv("HEALTH_CHECK_TIMEOUT", "50ms"))
+	require.NoError(t, os.Setenv("HEALTH_DRAIN_DELAY", "0s"))
+	defer os.Unsetenv("HEALTH_CHECK_TIMEOUT")
+	defer os.Unsetenv("HEALTH_DRAIN_DELAY")
+
+	return health.New()
+}
+
+func okCheck(ctx context.Context) error {
+	return nil
+}
+
+func failingCheck(ctx context.Context) error {
+	return errors.New("unreachable")
+}
+
+func TestRun_Unit_Liveness(t *testing.T) {
+	registry := newRegistry(t)
+	registry.Register("db", true, failingCheck, health.Readiness)
+
+	report := registry.Run(context.Background(), health.Liveness)
+
+	assert.True(t, report.Healthy())
+	assert.Equal(t, health.StatusOk, report.Status)
+	assert.Empty(t, report.Checks)
+}
+
+func TestRun_Unit_Startup(t *testing.T) {
+	registry := newRegistry(t)
+	registry.Register("migrations", true, okCheck, health.Startup)
+
+	assert.False(t, registry.Run(context.Background(), health.Startup).Healthy())
+
+	registry.MarkStarted()
+	report := registry.Run(context.Background(), health.Startup)
+
+	assert.True(t, report.Healthy())
+	assert.Contains(t, report.Checks, "migrations")
+}
+
+func TestRun_Unit_Readiness_Critical(t *testing.T) {
+	registry := newRegistry(t)
+	registry.MarkStarted()
+	registry.Register("db", true, failingCheck, health.Readiness)
+
+	report := registry.Run(context.Background(), health.Readiness)
+
+	assert.False(t, report.Healthy())
+	assert.Equal(t, health.StatusFailed, report.Status)
+	assert.Equal(t, "unreachable", report.Checks["db"].Error)
+}
+
+func TestRun_Unit_Readiness_NonCritical(t *testing.T) {
+	registry := newRegistry(t)
+	registry.MarkStarted()
+	registry.Register("db", true, okCheck, health.Readiness)
+	registry.Register("tracer", false, failingCheck, health.Readiness)
+
+	report := registry.Run(context.Background(), health.Readiness)
+
+	assert.True(t, report.Healthy())
+	assert.Equal(t, health.StatusDegraded, report.Status)
+}
+
+func TestRun_Unit_Readiness_Draining(t *testing.T) {
+	registry := newRegistry(t)
+	registry.MarkStarted()
+	registry.Register("db", true, okCheck, health.Readiness)
+
+	assert.True(t, registry.Run(context.Background(), health.Readiness).Healthy())
+
+	registry.Drain()
+
+	assert.False(t, registry.Run(context.Background(), health.Readiness).Healthy())
+	assert.True(t, registry.Run(context.Background(), health.Liveness).Healthy())
+}
+
+func TestRun_Unit_Timeout(t *testing.T) {
+	registry := newRegistry(t)
+	registry.MarkStarted()
+	registry.Register("slow", true, func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}, health.Readiness)
+
+	start := time.Now()
+	report := registry.Run(context.Background(), health.Readiness)
+
+	assert.False(t, report.Healthy())
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+func TestRun_Unit_Panic(t *testing.T) {
+	registry := newRegistry(t)
+	registry.Register("broken", true, func(ctx context.Context) error {
+		panic("nil pointer")
+	}, health.Liveness)
+
+	report := registry.Run(context.Background(), health.Liveness)
+
+	assert.False(t, report.Healthy())
+	assert.Contains(t, report.Checks["broken"].Error, "nil pointer")
+}
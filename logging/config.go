@@ -14,10 +14,72 @@ const (
 	logLevelDefault    = "info"
 )
 
+const (
+	sinksEnvVar          = "LOG_SINKS"             // comma separated, available values: stdout (default), file, syslog
+	stdoutEncodingEnvVar = "LOG_STDOUT_ENCODING"   // console or json, default LOG_ENCODING
+	stdoutLevelEnvVar    = "LOG_STDOUT_LEVEL"      // minimum level written to the sink, default debug
+	fileEncodingEnvVar   = "LOG_FILE_ENCODING"     // console or json (default)
+	fileLevelEnvVar      = "LOG_FILE_LEVEL"        // minimum level written to the sink, default debug
+	filePathEnvVar       = "LOG_FILE_PATH"         // path of the file, rotated ones are kept next to it
+	fileMaxSizeEnvVar    = "LOG_FILE_MAX_SIZE_MB"  // size in megabytes rotating the file
+	fileMaxAgeEnvVar     = "LOG_FILE_MAX_AGE_DAYS" // days rotated files are kept, 0 to keep them regardless of age
+	fileMaxBackupsEnvVar = "LOG_FILE_MAX_BACKUPS"  // number of rotated files kept, 0 to keep them all
+	fileCompressEnvVar   = "LOG_FILE_COMPRESS"     // bool, whether rotated files are compressed with gzip
+	syslogEncodingEnvVar = "LOG_SYSLOG_ENCODING"   // console or json (default)
+	syslogLevelEnvVar    = "LOG_SYSLOG_LEVEL"      // minimum level written to the sink, default debug
+	syslogTagEnvVar      = "LOG_SYSLOG_TAG"        // tag of the messages, usually the program name
+
+	sinkEncodingDefault   = "json"
+	sinkLevelDefault      = "debug"
+	filePathDefault       = "go-postgres-cicd.log"
+	fileMaxSizeDefault    = 100
+	fileMaxAgeDefault     = 7
+	fileMaxBackupsDefault = 10
+	fileCompressDefault   = true
+	syslogTagDefault      = "go-postgres-cicd"
+)
+
+var sinksDefault = []string{stdoutSink}
+
 func loadConfig() (*config, error) {
 	fmt.Println("Load Logging configurations")
-	return &config{
-		encoding: utils.GetStringEnv(logEncodingEnvVar, logEncodingDefault),
+	encoding := utils.GetStringEnv(logEncodingEnvVar, logEncodingDefault)
+	cfg := &config{
+		encoding: encoding,
 		level:    utils.GetStringEnv(logLevelEnvVar, logLevelDefault),
-	}, nil
+		sinks:    utils.GetStringSliceEnv(sinksEnvVar, sinksDefault),
+		stdout: sinkConfig{
+			encoding: utils.GetStringEnv(stdoutEncodingEnvVar, encoding),
+			level:    utils.GetStringEnv(stdoutLevelEnvVar, sinkLevelDefault),
+		},
+		file: fileSinkConfig{
+			sinkConfig: sinkConfig{
+				encoding: utils.GetStringEnv(fileEncodingEnvVar, sinkEncodingDefault),
+				level:    utils.GetStringEnv(fileLevelEnvVar, sinkLevelDefault),
+			},
+			path:       utils.GetStringEnv(filePathEnvVar, filePathDefault),
+			maxSizeMb:  utils.GetIntEnv(fileMaxSizeEnvVar, fileMaxSizeDefault),
+			maxAgeDays: utils.GetIntEnv(fileMaxAgeEnvVar, fileMaxAgeDefault),
+			maxBackups: utils.GetIntEnv(fileMaxBackupsEnvVar, fileMaxBackupsDefault),
+			compress:   utils.GetBoolEnv(fileCompressEnvVar, fileCompressDefault),
+		},
+		syslog: syslogSinkConfig{
+			sinkConfig: sinkConfig{
+				encoding: utils.GetStringEnv(syslogEncodingEnvVar, sinkEncodingDefault),
+				level:    utils.GetStringEnv(syslogLevelEnvVar, sinkLevelDefault),
+			},
+			tag: utils.GetStringEnv(syslogTagEnvVar, syslogTagDefault),
+		},
+	}
+
+	if len(cfg.sinks) == 0 {
+		return nil, fmt.Errorf("no log sink configured in %s", sinksEnvVar)
+	}
+	for _, sink := range cfg.sinks {
+		if sink != stdoutSink && sink != fileSink && sink != syslogSink {
+			return nil, fmt.Errorf("log sink %q not supported, available sinks: %s, %s, %s",
+				sink, stdoutSink, fileSink, syslogSink)
+		}
+	}
+	return cfg, nil
 }
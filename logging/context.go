@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIdKey = "request_id"
+	TraceIdKey   = "trace_id"
+	SpanIdKey    = "span_id"
+	UserKey      = "user"
+)
+
+type requestIdContextKey struct{}
+
+type userContextKey struct{}
+
+// SpanIdsFunc returns the trace and span IDs of a span context, empty if the tracer has none, e.g. the no-op one.
+type SpanIdsFunc func(spanContext opentracing.SpanContext) (traceId string, spanId string)
+
+// spanIds is set by the tracing package, which logs through this one and can't be imported by it
+var spanIds SpanIdsFunc
+
+// SetSpanIdsFunc sets how the trace and span IDs attached by FromContext are read from the span of the context.
+func SetSpanIdsFunc(ids SpanIdsFunc) {
+	spanIds = ids
+}
+
+// WithRequestId returns a copy of the context carrying the ID of the request it serves.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, requestId)
+}
+
+// RequestId returns the request ID carried by the context, empty if none.
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdContextKey{}).(string)
+	return requestId
+}
+
+// WithUser returns a copy of the context carrying the authenticated user of the request it serves.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// User returns the user carried by the context, empty if none, e.g. for anonymous requests.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey{}).(string)
+	return user
+}
+
+// FromContext returns the global logger with the trace and span IDs of the span of the context, its request ID and
+// its user, if any, attached to every line.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	return withContext(SugaredLog, ctx)
+}
+
+func withContext(logger *zap.SugaredLogger, ctx context.Context) *zap.SugaredLogger {
+	fields := make([]interface{}, 0, 8)
+	if span := opentracing.SpanFromContext(ctx); span != nil && spanIds != nil {
+		if traceId, spanId := spanIds(span.Context()); traceId != "" {
+			fields = append(fields, TraceIdKey, traceId, SpanIdKey, spanId)
+		}
+	}
+	if requestId := RequestId(ctx); requestId != "" {
+		fields = append(fields, RequestIdKey, requestId)
+	}
+	if user := User(ctx); user != "" {
+		fields = append(fields, UserKey, user)
+	}
+
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RootLogger names the global logger, whose level applies to the loggers without a level of their own
+	RootLogger     = "root"
+	RestLogger     = "rest"
+	DatabaseLogger = "database"
+	TracingLogger  = "tracing"
+)
+
+// namedLoggers are the loggers whose level can be changed apart from the root one
+var namedLoggers = []string{RestLogger, DatabaseLogger, TracingLogger}
+
+// levels holds the level of the root logger and the ones set on named loggers, and the pending reverts.
+type levels struct {
+	mutex   sync.RWMutex
+	root    zap.AtomicLevel
+	named   map[string]zapcore.Level
+	reverts map[string]*levelRevert
+}
+
+// levelRevert restores the levels of a logger as they were before a change with TTL.
+type levelRevert struct {
+	timer *time.Timer
+	// level is the previous level, nil if the logger followed the root one
+	level *zapcore.Level
+}
+
+// levelsCore filters the entries by the level of their logger, so that each named logger can have its own.
+type levelsCore struct {
+	zapcore.Core
+	levels *levels
+}
+
+var (
+	loggerLevels     *levels
+	namedSugaredLogs map[string]*zap.SugaredLogger
+)
+
+func newLevels(root zap.AtomicLevel) *levels {
+	return &levels{
+		root:    root,
+		named:   map[string]zapcore.Level{},
+		reverts: map[string]*levelRevert{},
+	}
+}
+
+// Named returns the named logger, e.g. RestLogger, whose level can be changed with SetLevel.
+func Named(name string) *zap.SugaredLogger {
+	if logger, found := namedSugaredLogs[name]; found {
+		return logger
+	}
+	return SugaredLog.Named(name)
+}
+
+// NamedFromContext returns the named logger with the same fields of FromContext attached to every line.
+func NamedFromContext(name string, ctx context.Context) *zap.SugaredLogger {
+	return withContext(Named(name), ctx)
+}
+
+// SetLevel sets the level of a named logger, or of the root one. With a positive TTL the change is reverted once
+// expired, to the levels before the first change still pending.
+func SetLevel(name string, level zapcore.Level, ttl time.Duration) error {
+	if !isKnownLogger(name) {
+		return fmt.Errorf("logger %q not found, available loggers: %s, %s", name, RootLogger,
+			strings.Join(namedLoggers, ", "))
+	}
+	loggerLevels.set(name, level, ttl)
+	SugaredLog.Warnf("Log level of %s logger set to %s, TTL %s", name, level, ttl)
+	return nil
+}
+
+// StepLevel makes the root logger more verbose by a negative step, less verbose by a positive one, between debug
+// and fatal. It returns the new level.
+func StepLevel(step int) zapcore.Level {
+	level := loggerLevels.root.Level() + zapcore.Level(step)
+	if level < zapcore.DebugLevel {
+		level = zapcore.DebugLevel
+	}
+	if level > zapcore.FatalLevel {
+		level = zapcore.FatalLevel
+	}
+	loggerLevels.set(RootLogger, level, 0)
+	SugaredLog.Warnf("Log level of %s logger stepped to %s", RootLogger, level)
+	return level
+}
+
+// GetLevels returns the effective level of every logger, by name.
+func GetLevels() map[string]string {
+	result := map[string]string{RootLogger: loggerLevels.root.Level().String()}
+	for _, name := range namedLoggers {
+		result[name] = loggerLevels.levelOf(name).String()
+	}
+	return result
+}
+
+func isKnownLogger(name string) bool {
+	if name == RootLogger {
+		return true
+	}
+	for _, named := range namedLoggers {
+		if named == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *levels) set(name string, level zapcore.Level, ttl time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	revert, pending := l.reverts[name]
+	if pending {
+		revert.timer.Stop()
+		delete(l.reverts, name)
+	}
+	if ttl > 0 {
+		if !pending {
+			revert = &levelRevert{level: l.current(name)}
+		}
+		revert.timer = time.AfterFunc(ttl, func() { l.revert(name, revert) })
+		l.reverts[name] = revert
+	}
+	l.apply(name, &level)
+}
+
+func (l *levels) revert(name string, revert *levelRevert) {
+	l.mutex.Lock()
+	// a later change may have replaced this revert
+	if l.reverts[name] != revert {
+		l.mutex.Unlock()
+		return
+	}
+	delete(l.reverts, name)
+	l.apply(name, revert.level)
+	// logging checks the levels, so the lock is released first
+	l.mutex.Unlock()
+
+	SugaredLog.Warnf("Log level of %s logger reverted after TTL", name)
+}
+
+// current returns the level set on the logger, nil for a named logger following the root one.
+func (l *levels) current(name string) *zapcore.Level {
+	if name == RootLogger {
+		level := l.root.Level()
+		return &level
+	}
+	if level, found := l.named[name]; found {
+		return &level
+	}
+	return nil
+}
+
+func (l *levels) apply(name string, level *zapcore.Level) {
+	switch {
+	case name == RootLogger:
+		l.root.SetLevel(*level)
+	case level == nil:
+		delete(l.named, name)
+	default:
+		l.named[name] = *level
+	}
+}
+
+// levelOf returns the level of the logger, the root one for loggers without a level of their own.
+func (l *levels) levelOf(loggerName string) zapcore.Level {
+	// children of named loggers, e.g. "rest.images", share their level
+	if index := strings.IndexByte(loggerName, '.'); index >= 0 {
+		loggerName = loggerName[:index]
+	}
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if level, found := l.named[loggerName]; found {
+		return level
+	}
+	return l.root.Level()
+}
+
+// Enabled admits the levels enabled by any logger, Check filters them by logger afterwards.
+func (c *levelsCore) Enabled(level zapcore.Level) bool {
+	if c.levels.root.Enabled(level) {
+		return true
+	}
+	c.levels.mutex.RLock()
+	defer c.levels.mutex.RUnlock()
+	for _, named := range c.levels.named {
+		if named.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *levelsCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.levelOf(entry.LoggerName).Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+func (c *levelsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelsCore{Core: c.Core.With(fields), levels: c.levels}
+}
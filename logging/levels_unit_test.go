@@ -0,0 +1,96 @@
+// +build !integration
+
+package logging_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	levelTTL      = 50 * time.Millisecond
+	revertTimeout = time.Second
+	revertTick    = 10 * time.Millisecond
+)
+
+func enabled(name string, level zapcore.Level) bool {
+	return logging.Named(name).Desugar().Check(level, "check") != nil
+}
+
+func TestSetLevel_Unit_NamedLogger(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	require.NoError(t, logging.SetLevel(logging.RestLogger, zapcore.DebugLevel, 0))
+
+	levels := logging.GetLevels()
+	assert.Equal(t, "debug", levels[logging.RestLogger])
+	assert.Equal(t, "info", levels[logging.RootLogger])
+	assert.Equal(t, "info", levels[logging.DatabaseLogger])
+	assert.True(t, enabled(logging.RestLogger, zapcore.DebugLevel))
+	assert.False(t, enabled(logging.DatabaseLogger, zapcore.DebugLevel))
+	assert.False(t, logging.SugaredLog.Desugar().Check(zapcore.DebugLevel, "check") != nil)
+	// children of named loggers share their level
+	assert.True(t, logging.Named(logging.RestLogger).Named("images").Desugar().Check(zapcore.DebugLevel, "check") != nil)
+}
+
+func TestSetLevel_Unit_Fail_UnknownLogger(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	err := logging.SetLevel("unknown", zapcore.DebugLevel, 0)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `logger "unknown" not found`)
+}
+
+func TestSetLevel_Unit_RevertNamedLogger(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	require.NoError(t, logging.SetLevel(logging.RestLogger, zapcore.DebugLevel, levelTTL))
+	require.NoError(t, logging.SetLevel(logging.RestLogger, zapcore.ErrorLevel, levelTTL))
+	assert.Equal(t, "error", logging.GetLevels()[logging.RestLogger])
+
+	// the logger followed the root one before the first change
+	assert.Eventually(t, func() bool {
+		return logging.GetLevels()[logging.RestLogger] == "info"
+	}, revertTimeout, revertTick)
+	require.NoError(t, logging.SetLevel(logging.RootLogger, zapcore.WarnLevel, 0))
+	assert.Equal(t, "warn", logging.GetLevels()[logging.RestLogger])
+}
+
+func TestSetLevel_Unit_RevertRootLogger(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	require.NoError(t, logging.SetLevel(logging.RootLogger, zapcore.DebugLevel, levelTTL))
+	require.NoError(t, logging.SetLevel(logging.RootLogger, zapcore.WarnLevel, levelTTL))
+	assert.Equal(t, "warn", logging.GetLevels()[logging.RootLogger])
+
+	assert.Eventually(t, func() bool {
+		return logging.GetLevels()[logging.RootLogger] == "info"
+	}, revertTimeout, revertTick)
+}
+
+func TestSetLevel_Unit_NoTTLCancelsRevert(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	require.NoError(t, logging.SetLevel(logging.DatabaseLogger, zapcore.DebugLevel, levelTTL))
+	require.NoError(t, logging.SetLevel(logging.DatabaseLogger, zapcore.WarnLevel, 0))
+
+	time.Sleep(2 * levelTTL)
+	assert.Equal(t, "warn", logging.GetLevels()[logging.DatabaseLogger])
+}
+
+func TestStepLevel_Unit_Clamped(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	assert.Equal(t, zapcore.DebugLevel, logging.StepLevel(-1))
+	assert.Equal(t, zapcore.DebugLevel, logging.StepLevel(-1))
+	assert.Equal(t, zapcore.FatalLevel, logging.StepLevel(100))
+	assert.Equal(t, zapcore.ErrorLevel, logging.StepLevel(-3))
+	assert.Equal(t, "error", logging.GetLevels()[logging.RootLogger])
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"fmt"
+	"os"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -24,15 +25,27 @@ func InitGlobalLogger() error {
 		return levelErr
 	}
 
+	// the outputs are the sinks, each with its own encoding and level
 	Config = &zap.Config{
-		Encoding:         cfg.encoding,
-		Level:            zap.NewAtomicLevelAt(level),
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
-		EncoderConfig:    buildEncoderConfig(level),
+		Encoding:      cfg.encoding,
+		Level:         zap.NewAtomicLevelAt(level),
+		EncoderConfig: buildEncoderConfig(level),
 	}
-	Log, _ = Config.Build()
+	loggerLevels = newLevels(Config.Level)
+
+	cores, coresErr := buildSinkCores(cfg, Config.EncoderConfig)
+	if coresErr != nil {
+		return coresErr
+	}
+	// the sinks filter the entries by their own level, levelsCore by the level of their logger
+	core := &levelsCore{Core: zapcore.NewTee(cores...), levels: loggerLevels}
+
+	Log = zap.New(core, zap.AddCaller(), zap.ErrorOutput(zapcore.Lock(os.Stderr)))
 	SugaredLog = Log.Sugar()
+	namedSugaredLogs = make(map[string]*zap.SugaredLogger, len(namedLoggers))
+	for _, name := range namedLoggers {
+		namedSugaredLogs[name] = SugaredLog.Named(name)
+	}
 	return nil
 }
 
@@ -52,6 +65,7 @@ func buildEncoderConfig(level zapcore.Level) zapcore.EncoderConfig {
 			EncodeTime:   zapcore.ISO8601TimeEncoder,
 			LevelKey:     "level",
 			EncodeLevel:  zapcore.CapitalLevelEncoder,
+			NameKey:      "logger",
 			CallerKey:    "caller",
 			EncodeCaller: zapcore.ShortCallerEncoder,
 			MessageKey:   "message",
@@ -62,6 +76,7 @@ func buildEncoderConfig(level zapcore.Level) zapcore.EncoderConfig {
 			EncodeTime:  zapcore.ISO8601TimeEncoder,
 			LevelKey:    "level",
 			EncodeLevel: zapcore.CapitalLevelEncoder,
+			NameKey:     "logger",
 			MessageKey:  "message",
 		}
 	}
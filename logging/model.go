@@ -3,4 +3,29 @@ package logging
 type config struct {
 	encoding string
 	level    string
+	sinks    []string
+	stdout   sinkConfig
+	file     fileSinkConfig
+	syslog   syslogSinkConfig
+}
+
+// sinkConfig is the encoding of the entries written to a sink and the minimum level of them, on top of the level of
+// their logger.
+type sinkConfig struct {
+	encoding string
+	level    string
+}
+
+type fileSinkConfig struct {
+	sinkConfig
+	path       string
+	maxSizeMb  int
+	maxAgeDays int
+	maxBackups int
+	compress   bool
+}
+
+type syslogSinkConfig struct {
+	sinkConfig
+	tag string
 }
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	stdoutSink = "stdout"
+	fileSink   = "file"
+	syslogSink = "syslog"
+
+	consoleEncoding = "console"
+	jsonEncoding    = "json"
+)
+
+// syslogCore writes the entries to the local syslog, with the severity matching their level.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	writer  *syslog.Writer
+}
+
+// buildSinkCores returns a core for each configured sink, with its own encoding and minimum level.
+func buildSinkCores(cfg *config, encoderConfig zapcore.EncoderConfig) ([]zapcore.Core, error) {
+	cores := make([]zapcore.Core, 0, len(cfg.sinks))
+	for _, sink := range cfg.sinks {
+		var core zapcore.Core
+		var err error
+		switch sink {
+		case stdoutSink:
+			core, err = newSinkCore(cfg.stdout, encoderConfig, zapcore.Lock(os.Stdout))
+		case fileSink:
+			core, err = newSinkCore(cfg.file.sinkConfig, encoderConfig, zapcore.AddSync(newFileWriter(cfg.file)))
+		case syslogSink:
+			core, err = newSyslogCore(cfg.syslog, encoderConfig)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("log sink %s setup failed: %s", sink, err.Error())
+		}
+		cores = append(cores, core)
+	}
+	return cores, nil
+}
+
+func newSinkCore(cfg sinkConfig, encoderConfig zapcore.EncoderConfig, writer zapcore.WriteSyncer) (zapcore.Core, error) {
+	encoder, level, err := buildSinkEncoding(cfg, encoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	return zapcore.NewCore(encoder, writer, level), nil
+}
+
+// newFileWriter rotates the file once it reaches the max size, and removes the rotated ones by age and number.
+func newFileWriter(cfg fileSinkConfig) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   cfg.path,
+		MaxSize:    cfg.maxSizeMb,
+		MaxAge:     cfg.maxAgeDays,
+		MaxBackups: cfg.maxBackups,
+		Compress:   cfg.compress,
+	}
+}
+
+func newSyslogCore(cfg syslogSinkConfig, encoderConfig zapcore.EncoderConfig) (zapcore.Core, error) {
+	// syslog adds its own timestamp
+	encoderConfig.TimeKey = ""
+	encoder, level, err := buildSinkEncoding(cfg.sinkConfig, encoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := syslog.New(syslog.LOG_USER|syslog.LOG_INFO, cfg.tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogCore{LevelEnabler: level, encoder: encoder, writer: writer}, nil
+}
+
+func buildSinkEncoding(cfg sinkConfig, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, zapcore.Level, error) {
+	level, err := getZapLevel(cfg.level)
+	if err != nil {
+		return nil, level, err
+	}
+
+	switch cfg.encoding {
+	case consoleEncoding:
+		return zapcore.NewConsoleEncoder(encoderConfig), level, nil
+	case jsonEncoding:
+		return zapcore.NewJSONEncoder(encoderConfig), level, nil
+	default:
+		return nil, level, fmt.Errorf("log encoding %q not supported, available encodings: %s, %s",
+			cfg.encoding, consoleEncoding, jsonEncoding)
+	}
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &syslogCore{LevelEnabler: c.LevelEnabler, encoder: encoder, writer: c.writer}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	message := buffer.String()
+	buffer.Free()
+
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(message)
+	case zapcore.InfoLevel:
+		return c.writer.Info(message)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(message)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(message)
+	default:
+		// dpanic, panic and fatal
+		return c.writer.Crit(message)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/bygui86/go-postgres-cicd/commons"
 	"github.com/bygui86/go-postgres-cicd/config"
+	"github.com/bygui86/go-postgres-cicd/health"
 	"github.com/bygui86/go-postgres-cicd/logging"
 	"github.com/bygui86/go-postgres-cicd/monitoring"
 	"github.com/bygui86/go-postgres-cicd/rest"
@@ -23,6 +25,7 @@ const (
 )
 
 var (
+	healthRegistry   *health.Registry
 	monitoringServer *monitoring.Server
 	jaegerCloser     io.Closer
 	zipkinReporter   reporter.Reporter
@@ -32,12 +35,14 @@ var (
 func main() {
 	initLogging()
 
-	logging.SugaredLog.Infof("Start %s", commons.ServiceName)
+	logging.SugaredLog.Infof("Start %s, version %s, commit %s", commons.ServiceName, commons.Version, commons.Commit)
 
 	cfg := loadConfig()
 
+	healthRegistry = health.New()
+
 	if cfg.EnableMonitoring() {
-		monitoringServer = startMonitoringServer()
+		monitoringServer = startMonitoringServer(healthRegistry)
 	}
 
 	if cfg.EnableTracing() {
@@ -47,10 +52,18 @@ func main() {
 		case config.TracingTechZipkin:
 			zipkinReporter = initZipkinTracer()
 		}
+		// traces are not worth taking the service out of traffic
+		healthRegistry.Register("tracer-reporter", false, tracing.CheckReporter, health.Readiness)
 	}
 
 	restServer = startRestServer()
+	restServer.RegisterHealthChecks(healthRegistry)
+	if monitoringServer != nil {
+		registerDbMetrics(monitoringServer, restServer)
+	}
 
+	healthRegistry.MarkStarted()
+	startLogLevelSignals()
 	logging.SugaredLog.Infof("%s up and running", commons.ServiceName)
 
 	startSysCallChannel()
@@ -61,7 +74,8 @@ func main() {
 func initLogging() {
 	err := logging.InitGlobalLogger()
 	if err != nil {
-		logging.SugaredLog.Errorf("Logging setup failed: %s", err.Error())
+		// the logger is not available
+		fmt.Printf("Logging setup failed: %s\n", err.Error())
 		os.Exit(501)
 	}
 }
@@ -71,9 +85,9 @@ func loadConfig() *config.Config {
 	return config.LoadConfig()
 }
 
-func startMonitoringServer() *monitoring.Server {
+func startMonitoringServer(registry *health.Registry) *monitoring.Server {
 	logging.Log.Debug("Start monitoring")
-	server := monitoring.New()
+	server := monitoring.New(registry)
 	logging.Log.Debug("Monitoring server successfully created")
 
 	server.Start()
@@ -119,20 +133,54 @@ func startRestServer() *rest.Server {
 	}
 	logging.Log.Debug("REST server successfully started")
 
-	rest.RegisterCustomMetrics()
+	metricsErr := rest.RegisterCustomMetrics()
+	if metricsErr != nil {
+		logging.SugaredLog.Errorf("REST metrics registration failed: %s", metricsErr.Error())
+		os.Exit(503)
+	}
 
 	return server
 }
 
+func registerDbMetrics(monitoringServer *monitoring.Server, restServer *rest.Server) {
+	logging.Log.Debug("Register DB metrics")
+
+	err := monitoringServer.RegisterCollectors(restServer.DbMetrics()...)
+	if err != nil {
+		logging.SugaredLog.Errorf("DB metrics registration failed: %s", err.Error())
+		os.Exit(503)
+	}
+}
+
 func startSysCallChannel() {
-	syscallCh := make(chan os.Signal)
+	syscallCh := make(chan os.Signal, 1)
 	signal.Notify(syscallCh, syscall.SIGTERM, syscall.SIGINT, os.Interrupt)
 	<-syscallCh
 }
 
+// startLogLevelSignals steps the level of the root logger: SIGUSR1 makes it more verbose, SIGUSR2 less verbose.
+func startLogLevelSignals() {
+	levelCh := make(chan os.Signal, 1)
+	signal.Notify(levelCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range levelCh {
+			if sig == syscall.SIGUSR1 {
+				logging.StepLevel(-1)
+			} else {
+				logging.StepLevel(1)
+			}
+		}
+	}()
+}
+
 func shutdownAndWait(timeout int) {
 	logging.SugaredLog.Warnf("Termination signal received! Timeout %d", timeout)
 
+	// readiness fails first, so that traffic is drained before the REST server and the DB are closed
+	if healthRegistry != nil {
+		healthRegistry.Drain()
+	}
+
 	if restServer != nil {
 		restServer.Shutdown(timeout)
 	}
@@ -6,17 +6,20 @@ import (
 )
 
 const (
-	monitorHostEnvVar = "MONITOR_HOST"
-	monitorPortEnvVar = "MONITOR_PORT"
+	monitorHostEnvVar      = "MONITOR_HOST"
+	monitorPortEnvVar      = "MONITOR_PORT"
+	diagnosticsTokenEnvVar = "MONITOR_DIAGNOSTICS_TOKEN" // bearer token required by pprof, expvar and dumps, empty for none
 
-	monitorHostDefault = "0.0.0.0"
-	monitorPortDefault = 9090
+	monitorHostDefault      = "0.0.0.0"
+	monitorPortDefault      = 9090
+	diagnosticsTokenDefault = ""
 )
 
 func loadConfig() *Config {
 	logging.Log.Debug("Load monitoring configurations")
 	return &Config{
-		restHost: utils.GetStringEnv(monitorHostEnvVar, monitorHostDefault),
-		restPort: utils.GetIntEnv(monitorPortEnvVar, monitorPortDefault),
+		restHost:         utils.GetStringEnv(monitorHostEnvVar, monitorHostDefault),
+		restPort:         utils.GetIntEnv(monitorPortEnvVar, monitorPortDefault),
+		diagnosticsToken: utils.GetStringEnv(diagnosticsTokenEnvVar, diagnosticsTokenDefault),
 	}
 }
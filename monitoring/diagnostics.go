@@ -0,0 +1,93 @@
+package monitoring
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	pprofPathPrefix      = "/debug/pprof/"
+	expvarEndpoint       = "/debug/vars"
+	goroutinesEndpoint   = "/debug/goroutines"
+	buildInfoEndpoint    = "/buildinfo"
+	authorizationHeader  = "Authorization"
+	bearerPrefix         = "Bearer "
+	goroutineProfileName = "goroutine"
+	// goroutineDumpDebug prints the goroutines with their full stack, like an unrecovered panic
+	goroutineDumpDebug = 2
+	// diagnosticsWriteTimeout lets CPU profiles and traces run for their default 30 seconds, and more
+	diagnosticsWriteTimeout = 2 * time.Minute
+)
+
+func init() {
+	expvar.Publish("build", expvar.Func(func() interface{} {
+		return commons.GetBuildInfo()
+	}))
+}
+
+func (s *Server) setupDiagnostics() {
+	if s.config.diagnosticsToken == "" {
+		logging.SugaredLog.Warnf("%s not set: diagnostics endpoints are served without authentication",
+			diagnosticsTokenEnvVar)
+	}
+
+	diagnostics := s.router.NewRoute().Subrouter()
+	diagnostics.Use(s.diagnosticsTokenMiddleware)
+
+	diagnostics.HandleFunc(pprofPathPrefix+"cmdline", pprof.Cmdline)
+	diagnostics.HandleFunc(pprofPathPrefix+"profile", pprof.Profile)
+	diagnostics.HandleFunc(pprofPathPrefix+"symbol", pprof.Symbol)
+	diagnostics.HandleFunc(pprofPathPrefix+"trace", pprof.Trace)
+	// the index serves the named profiles too, e.g. heap or goroutine
+	diagnostics.PathPrefix(pprofPathPrefix).HandlerFunc(pprof.Index)
+	diagnostics.Handle(expvarEndpoint, expvar.Handler())
+	diagnostics.HandleFunc(goroutinesEndpoint, dumpGoroutines)
+	diagnostics.HandleFunc(buildInfoEndpoint, getBuildInfo)
+	diagnostics.HandleFunc(logLevelEndpoint, getLogLevels).Methods(http.MethodGet)
+	diagnostics.HandleFunc(logLevelEndpoint, setLogLevel).Methods(http.MethodPut)
+}
+
+// diagnosticsTokenMiddleware requires the configured token as bearer token, if any, since profiles and dumps expose
+// the internals of the process, and log levels change its behaviour.
+func (s *Server) diagnosticsTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if s.config.diagnosticsToken == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		authorization := request.Header.Get(authorizationHeader)
+		if !strings.HasPrefix(authorization, bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(authorization[len(bearerPrefix):]), []byte(s.config.diagnosticsToken)) != 1 {
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+func dumpGoroutines(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	err := runtimepprof.Lookup(goroutineProfileName).WriteTo(writer, goroutineDumpDebug)
+	if err != nil {
+		logging.SugaredLog.Errorf("Error dumping goroutines: %s", err.Error())
+	}
+}
+
+func getBuildInfo(writer http.ResponseWriter, request *http.Request) {
+	response, _ := json.Marshal(commons.GetBuildInfo())
+	writer.Header().Set("Content-Type", "application/json")
+	_, err := writer.Write(response)
+	if err != nil {
+		logging.SugaredLog.Errorf("Error sending build info: %s", err.Error())
+	}
+}
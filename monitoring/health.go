@@ -0,0 +1,36 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bygui86/go-postgres-cicd/health"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	livenessEndpoint  = "/livez"
+	readinessEndpoint = "/readyz"
+	startupEndpoint   = "/startupz"
+)
+
+// probeHandler answers 200 with the report of the probe if it succeeds, 503 otherwise.
+func (s *Server) probeHandler(probe health.Probe) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		report := s.health.Run(request.Context(), probe)
+
+		status := http.StatusOK
+		if !report.Healthy() {
+			status = http.StatusServiceUnavailable
+			logging.SugaredLog.Warnf("Health probe %s failed: %+v", probe, report.Checks)
+		}
+
+		response, _ := json.Marshal(report)
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(status)
+		_, err := writer.Write(response)
+		if err != nil {
+			logging.SugaredLog.Errorf("Error sending %s probe response: %s", probe, err.Error())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const logLevelEndpoint = "/loglevel"
+
+// logLevelChange is the body of PUT /loglevel, e.g. {"logger":"rest","level":"debug","ttl":"5m"}
+type logLevelChange struct {
+	// Logger defaults to the root one
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	// TTL is optional, the change is permanent without it
+	TTL string `json:"ttl"`
+}
+
+func getLogLevels(writer http.ResponseWriter, request *http.Request) {
+	sendLogLevels(writer)
+}
+
+func setLogLevel(writer http.ResponseWriter, request *http.Request) {
+	var change logLevelChange
+	err := json.NewDecoder(request.Body).Decode(&change)
+	if err != nil {
+		http.Error(writer, fmt.Sprintf("invalid request payload: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	if change.Logger == "" {
+		change.Logger = logging.RootLogger
+	}
+	var level zapcore.Level
+	err = level.UnmarshalText([]byte(change.Level))
+	if err != nil {
+		http.Error(writer, fmt.Sprintf("invalid level %q", change.Level), http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if change.TTL != "" {
+		ttl, err = time.ParseDuration(change.TTL)
+		if err != nil || ttl < 0 {
+			http.Error(writer, fmt.Sprintf("invalid TTL %q", change.TTL), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = logging.SetLevel(change.Logger, level, ttl)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sendLogLevels(writer)
+}
+
+func sendLogLevels(writer http.ResponseWriter) {
+	response, _ := json.Marshal(logging.GetLevels())
+	writer.Header().Set("Content-Type", "application/json")
+	_, err := writer.Write(response)
+	if err != nil {
+		logging.SugaredLog.Errorf("Error sending log levels: %s", err.Error())
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/health"
 )
 
 type Config struct {
-	restHost string
-	restPort int
+	restHost         string
+	restPort         int
+	diagnosticsToken string
 }
 
 type Server struct {
 	config     *Config
 	router     *mux.Router
+	health     *health.Registry
 	httpServer *http.Server
 	running    bool
 }
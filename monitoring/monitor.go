@@ -4,15 +4,19 @@ import (
 	"context"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bygui86/go-postgres-cicd/health"
 	"github.com/bygui86/go-postgres-cicd/logging"
 )
 
-func New() *Server {
+func New(registry *health.Registry) *Server {
 	logging.Log.Info("Create new monitoring server")
 
 	cfg := loadConfig()
 	server := &Server{
 		config: cfg,
+		health: registry,
 	}
 	server.newRouter()
 	server.newHTTPServer()
@@ -55,3 +59,14 @@ func (s *Server) Shutdown(timeout int) {
 
 	logging.Log.Error("Monitoring server shutdown failed: HTTP server not initialized or HTTP server not running")
 }
+
+// RegisterCollectors adds collectors to the metrics exposed by the server, e.g. the ones of the DB pool.
+func (s *Server) RegisterCollectors(collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		err := prometheus.Register(collector)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/health"
 	"github.com/bygui86/go-postgres-cicd/logging"
 )
 
@@ -16,7 +18,13 @@ func (s *Server) newRouter() {
 
 	s.router = mux.NewRouter().StrictSlash(true)
 
-	s.router.Handle("/metrics", promhttp.Handler())
+	// OpenMetrics, when asked by the scraper, is the only format exposing the exemplars of the histograms
+	s.router.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+	s.router.HandleFunc(livenessEndpoint, s.probeHandler(health.Liveness))
+	s.router.HandleFunc(readinessEndpoint, s.probeHandler(health.Readiness))
+	s.router.HandleFunc(startupEndpoint, s.probeHandler(health.Startup))
+	s.setupDiagnostics()
 }
 
 func (s *Server) newHTTPServer() {
@@ -27,7 +35,7 @@ func (s *Server) newHTTPServer() {
 			Addr:    fmt.Sprintf(commons.HttpServerHostFormat, s.config.restHost, s.config.restPort),
 			Handler: s.router,
 			// Good practice to set timeouts to avoid Slowloris attacks.
-			WriteTimeout: commons.HttpServerWriteTimeoutDefault,
+			WriteTimeout: diagnosticsWriteTimeout,
 			ReadTimeout:  commons.HttpServerReadTimeoutDefault,
 			IdleTimeout:  commons.HttpServerIdelTimeoutDefault,
 		}
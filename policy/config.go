@@ -0,0 +1,24 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	fileEnvVar           = "POLICY_FILE"            // JSON policy, empty to only enforce scopes
+	reloadIntervalEnvVar = "POLICY_RELOAD_INTERVAL" // duration, e.g. 10s
+
+	fileDefault           = ""
+	reloadIntervalDefault = 10 * time.Second
+)
+
+func loadConfig() *config {
+	logging.Log.Debug("Load policy configurations")
+	return &config{
+		file:           utils.GetStringEnv(fileEnvVar, fileDefault),
+		reloadInterval: utils.GetDurationEnv(reloadIntervalEnvVar, reloadIntervalDefault),
+	}
+}
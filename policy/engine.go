@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func New() (*Engine, error) {
+	logging.Log.Info("Create new policy engine")
+
+	engine := &Engine{config: loadConfig()}
+	if engine.config.file == "" {
+		logging.SugaredLog.Warnf("%s not set, actions are only restricted by scopes", fileEnvVar)
+		return engine, nil
+	}
+
+	loadErr := engine.load()
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	return engine, nil
+}
+
+// Evaluate decides whether the subject may perform the action, given the attributes of the resource it targets.
+// Actions are denied unless a permission of one of the subject roles allows them.
+func (e *Engine) Evaluate(subject *Subject, action string, attributes map[string]interface{}) *Decision {
+	decision := &Decision{Subject: subject.ID, Action: action}
+
+	policy := e.currentPolicy()
+	if policy == nil {
+		decision.Allowed = true
+		decision.Reason = "no policy"
+		return decision
+	}
+
+	roles := policy.roles(subject)
+	if len(roles) == 0 {
+		decision.Reason = "no role"
+		return decision
+	}
+
+	for _, roleName := range roles {
+		role, found := policy.Roles[roleName]
+		if !found {
+			continue
+		}
+		for _, permission := range role.Permissions {
+			if !permission.matches(action) {
+				continue
+			}
+			failed := failedCondition(permission.Conditions, attributes)
+			if failed == nil {
+				decision.Allowed = true
+				decision.Role = roleName
+				decision.Reason = "permission " + permission.Action
+				return decision
+			}
+			decision.Reason = fmt.Sprintf("condition %s %s %v not met", failed.Attribute, failed.Operator, failed.Value)
+		}
+	}
+
+	if decision.Reason == "" {
+		decision.Reason = "no permission for roles " + strings.Join(roles, ",")
+	}
+	return decision
+}
+
+func failedCondition(conditions []*Condition, attributes map[string]interface{}) *Condition {
+	for _, condition := range conditions {
+		if !condition.holds(attributes) {
+			return condition
+		}
+	}
+	return nil
+}
+
+// currentPolicy returns the policy, reloaded first if the file changed since the last check.
+// A file that became invalid is reported and ignored, the previous policy stays in force.
+func (e *Engine) currentPolicy() *Policy {
+	if e.config.file == "" {
+		return nil
+	}
+
+	e.mutex.RLock()
+	policy := e.policy
+	stale := time.Since(e.checkedAt) >= e.config.reloadInterval
+	e.mutex.RUnlock()
+	if !stale {
+		return policy
+	}
+
+	reloadErr := e.load()
+	if reloadErr != nil {
+		logging.SugaredLog.Errorf("Policy reload from %s failed, keeping the previous one: %s",
+			e.config.file, reloadErr.Error())
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.policy
+}
+
+// load reads the policy file if its modification time changed.
+func (e *Engine) load() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.checkedAt = time.Now()
+
+	info, statErr := os.Stat(e.config.file)
+	if statErr != nil {
+		return statErr
+	}
+	if e.policy != nil && info.ModTime().Equal(e.modTime) {
+		return nil
+	}
+
+	content, readErr := ioutil.ReadFile(e.config.file)
+	if readErr != nil {
+		return readErr
+	}
+	policy, parseErr := Parse(content)
+	if parseErr != nil {
+		return fmt.Errorf("policy %s not valid: %s", e.config.file, parseErr.Error())
+	}
+
+	logging.SugaredLog.Infof("Policy loaded from %s: %d roles", e.config.file, len(policy.Roles))
+	e.policy = policy
+	e.modTime = info.ModTime()
+	return nil
+}
@@ -0,0 +1,158 @@
+// +build !integration
+
+package policy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/policy"
+)
+
+const (
+	fileKey           = "POLICY_FILE"
+	reloadIntervalKey = "POLICY_RELOAD_INTERVAL"
+
+	updateAction = "product:update"
+	deleteAction = "product:delete"
+
+	editorsPolicy = `{
+	"roles": {
+		"editor": {"permissions": [
+			{"action": "product:create"},
+			{"action": "product:update", "conditions": [
+				{"attribute": "price_change_percent", "operator": "lte", "value": 20},
+				{"attribute": "category", "operator": "in", "value": ["t-shirts", "hoodies"]}
+			]}
+		]},
+		"admin": {"permissions": [{"action": "product:*"}]}
+	},
+	"scope_roles": {"products:write": ["editor"], "products:admin": ["admin"]},
+	"subject_roles": {"root": ["admin"]}
+}`
+	adminsOnlyPolicy = `{"roles": {"admin": {"permissions": [{"action": "*"}]}}, "scope_roles": {"products:admin": ["admin"]}}`
+)
+
+var (
+	editor = &policy.Subject{ID: "editor-1", Scopes: []string{"products:write"}}
+	admin  = &policy.Subject{ID: "admin-1", Scopes: []string{"products:admin"}}
+)
+
+func writePolicy(t *testing.T, path, content string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+}
+
+func newEngine(t *testing.T, content string) (*policy.Engine, string) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy(t, path, content)
+	require.NoError(t, os.Setenv(fileKey, path))
+	defer os.Unsetenv(fileKey)
+
+	engine, err := policy.New()
+	require.NoError(t, err)
+	return engine, path
+}
+
+func TestEvaluate_Unit_Conditions(t *testing.T) {
+	engine, _ := newEngine(t, editorsPolicy)
+
+	tests := []struct {
+		name       string
+		subject    *policy.Subject
+		action     string
+		attributes map[string]interface{}
+		allowed    bool
+		role       string
+	}{
+		{"editor small change", editor, updateAction,
+			map[string]interface{}{"price_change_percent": 20.0, "category": "t-shirts"}, true, "editor"},
+		{"editor large change", editor, updateAction,
+			map[string]interface{}{"price_change_percent": 25.0, "category": "t-shirts"}, false, ""},
+		{"editor other category", editor, updateAction,
+			map[string]interface{}{"price_change_percent": 5.0, "category": "shoes"}, false, ""},
+		{"editor missing attribute", editor, updateAction,
+			map[string]interface{}{"category": "t-shirts"}, false, ""},
+		{"editor delete", editor, deleteAction, nil, false, ""},
+		{"admin delete", admin, deleteAction, nil, true, "admin"},
+		{"subject binding", &policy.Subject{ID: "root"}, deleteAction, nil, true, "admin"},
+		{"token role", &policy.Subject{ID: "someone", Roles: []string{"editor"}}, "product:create", nil, true, "editor"},
+		{"no role", &policy.Subject{ID: "reader", Scopes: []string{"products:read"}}, "product:create", nil, false, ""},
+		{"admin other resource", admin, "promotion:delete", nil, false, ""},
+	}
+
+	for _, test := range tests {
+		decision := engine.Evaluate(test.subject, test.action, test.attributes)
+		assert.Equal(t, test.allowed, decision.Allowed, test.name+": "+decision.String())
+		assert.Equal(t, test.role, decision.Role, test.name)
+		assert.NotEmpty(t, decision.Reason, test.name)
+	}
+}
+
+func TestEvaluate_Unit_NoPolicy(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	engine, err := policy.New()
+	require.NoError(t, err)
+
+	assert.True(t, engine.Evaluate(editor, deleteAction, nil).Allowed)
+}
+
+func TestEvaluate_Unit_Reload(t *testing.T) {
+	require.NoError(t, os.Setenv(reloadIntervalKey, "1ns"))
+	defer os.Unsetenv(reloadIntervalKey)
+	engine, path := newEngine(t, editorsPolicy)
+
+	assert.True(t, engine.Evaluate(editor, "product:create", nil).Allowed)
+
+	writePolicy(t, path, adminsOnlyPolicy)
+	// make sure the modification time changes even on filesystems with coarse timestamps
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	assert.False(t, engine.Evaluate(editor, "product:create", nil).Allowed)
+	assert.True(t, engine.Evaluate(admin, "promotion:delete", nil).Allowed)
+
+	// an invalid file is ignored, the last valid policy stays in force
+	writePolicy(t, path, `{"roles": {"admin": {"permissions": [{"action": ""}]}}}`)
+	evenLater := later.Add(time.Second)
+	require.NoError(t, os.Chtimes(path, evenLater, evenLater))
+
+	assert.True(t, engine.Evaluate(admin, "promotion:delete", nil).Allowed)
+}
+
+func TestNew_Unit_Fail(t *testing.T) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.Setenv(fileKey, path))
+	defer os.Unsetenv(fileKey)
+
+	_, missingErr := policy.New()
+	assert.Error(t, missingErr)
+
+	writePolicy(t, path, `{"roles": {}, "scope_roles": {"products:write": ["editor"]}}`)
+	_, unknownRoleErr := policy.New()
+	assert.Error(t, unknownRoleErr)
+}
+
+func TestParse_Unit_Fail(t *testing.T) {
+	for _, content := range []string{
+		`null`,
+		`{"roles": {"editor": {"permissions": [{"action": "product:update", "conditions": [{"attribute": "price", "operator": "lte", "value": "20"}]}]}}}`,
+		`{"roles": {"editor": {"permissions": [{"action": "product:update", "conditions": [{"attribute": "category", "operator": "in", "value": "shoes"}]}]}}}`,
+		`{"roles": {"editor": {"permissions": [{"action": "product:update", "conditions": [{"attribute": "price", "operator": "like", "value": 1}]}]}}}`,
+		`{"roles": {"editor": {"permissions": [{"action": "product:update", "conditions": [{"operator": "eq", "value": 1}]}]}}}`,
+	} {
+		_, err := policy.Parse([]byte(content))
+		assert.Error(t, err, content)
+	}
+}
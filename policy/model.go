@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type config struct {
+	file           string
+	reloadInterval time.Duration
+}
+
+// Policy grants permissions to roles, and roles to principals by scope or by subject.
+type Policy struct {
+	Roles map[string]*Role `json:"roles"`
+	// ScopeRoles and SubjectRoles add up to the roles carried by the principal itself
+	ScopeRoles   map[string][]string `json:"scope_roles,omitempty"`
+	SubjectRoles map[string][]string `json:"subject_roles,omitempty"`
+}
+
+type Role struct {
+	Permissions []*Permission `json:"permissions"`
+}
+
+// Permission allows an action, e.g. "product:update", "product:*" or "*", when all its conditions hold.
+type Permission struct {
+	Action     string       `json:"action"`
+	Conditions []*Condition `json:"conditions,omitempty"`
+}
+
+// Condition compares an attribute of the request to a value, e.g. {"attribute": "price_change_percent",
+// "operator": "lte", "value": 20}. Conditions on missing attributes never hold.
+type Condition struct {
+	Attribute string      `json:"attribute"`
+	Operator  string      `json:"operator"`
+	Value     interface{} `json:"value"`
+}
+
+// Subject is the principal an action is evaluated for.
+type Subject struct {
+	ID     string
+	Roles  []string
+	Scopes []string
+}
+
+// Decision is the outcome of an evaluation, Role is the role whose permission allowed the action, if any.
+type Decision struct {
+	Subject string
+	Action  string
+	Allowed bool
+	Role    string
+	Reason  string
+}
+
+// Engine evaluates actions against the policy file, reloading it whenever it changes.
+// An engine without policy file allows every action.
+type Engine struct {
+	config *config
+
+	mutex     sync.RWMutex
+	policy    *Policy
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (d *Decision) String() string {
+	return fmt.Sprintf("Subject[%s], Action[%s], Allowed[%t], Role[%s], Reason[%s]",
+		d.Subject, d.Action, d.Allowed, d.Role, d.Reason)
+}
@@ -0,0 +1,146 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	OperatorEq  = "eq"
+	OperatorNe  = "ne"
+	OperatorLt  = "lt"
+	OperatorLte = "lte"
+	OperatorGt  = "gt"
+	OperatorGte = "gte"
+	OperatorIn  = "in"
+
+	anyAction = "*"
+)
+
+// Parse decodes and validates a policy document.
+func Parse(content []byte) (*Policy, error) {
+	var policy *Policy
+	unmarshErr := json.Unmarshal(content, &policy)
+	if unmarshErr != nil {
+		return nil, unmarshErr
+	}
+	if policy == nil {
+		return nil, fmt.Errorf("policy empty")
+	}
+
+	validateErr := policy.Validate()
+	if validateErr != nil {
+		return nil, validateErr
+	}
+	return policy, nil
+}
+
+func (p *Policy) Validate() error {
+	for name, role := range p.Roles {
+		if role == nil {
+			return fmt.Errorf("role %q empty", name)
+		}
+		for _, permission := range role.Permissions {
+			if permission == nil || permission.Action == "" {
+				return fmt.Errorf("role %q: permission action missing", name)
+			}
+			for _, condition := range permission.Conditions {
+				conditionErr := condition.validate()
+				if conditionErr != nil {
+					return fmt.Errorf("role %q, action %q: %s", name, permission.Action, conditionErr.Error())
+				}
+			}
+		}
+	}
+
+	for _, bindings := range []map[string][]string{p.ScopeRoles, p.SubjectRoles} {
+		for bound, roles := range bindings {
+			for _, role := range roles {
+				if _, found := p.Roles[role]; !found {
+					return fmt.Errorf("%q bound to unknown role %q", bound, role)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Condition) validate() error {
+	if c == nil || c.Attribute == "" {
+		return fmt.Errorf("condition attribute missing")
+	}
+	switch c.Operator {
+	case OperatorEq, OperatorNe:
+		return nil
+	case OperatorLt, OperatorLte, OperatorGt, OperatorGte:
+		if _, isNumber := c.Value.(float64); !isNumber {
+			return fmt.Errorf("condition on %q: operator %s needs a number", c.Attribute, c.Operator)
+		}
+		return nil
+	case OperatorIn:
+		if _, isList := c.Value.([]interface{}); !isList {
+			return fmt.Errorf("condition on %q: operator %s needs a list", c.Attribute, c.Operator)
+		}
+		return nil
+	default:
+		return fmt.Errorf("condition on %q: operator %q not valid", c.Attribute, c.Operator)
+	}
+}
+
+// roles returns the roles of the subject: its own ones plus the ones bound to its scopes and to its ID.
+func (p *Policy) roles(subject *Subject) []string {
+	roles := append([]string{}, subject.Roles...)
+	for _, scope := range subject.Scopes {
+		roles = append(roles, p.ScopeRoles[scope]...)
+	}
+	return append(roles, p.SubjectRoles[subject.ID]...)
+}
+
+func (p *Permission) matches(action string) bool {
+	if p.Action == anyAction || p.Action == action {
+		return true
+	}
+	return strings.HasSuffix(p.Action, ":*") && strings.HasPrefix(action, strings.TrimSuffix(p.Action, "*"))
+}
+
+// holds reports whether the condition is satisfied by the attributes. Numbers are compared as float64, the type
+// JSON numbers decode to, so numeric attributes must be given as float64 too.
+func (c *Condition) holds(attributes map[string]interface{}) bool {
+	value, found := attributes[c.Attribute]
+	if !found {
+		return false
+	}
+
+	switch c.Operator {
+	case OperatorEq:
+		return value == c.Value
+	case OperatorNe:
+		return value != c.Value
+	case OperatorIn:
+		for _, candidate := range c.Value.([]interface{}) {
+			if value == candidate {
+				return true
+			}
+		}
+		return false
+	}
+
+	number, isNumber := value.(float64)
+	if !isNumber {
+		return false
+	}
+	limit := c.Value.(float64)
+	switch c.Operator {
+	case OperatorLt:
+		return number < limit
+	case OperatorLte:
+		return number <= limit
+	case OperatorGt:
+		return number > limit
+	case OperatorGte:
+		return number >= limit
+	default:
+		return false
+	}
+}
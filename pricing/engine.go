@@ -0,0 +1,116 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+// Apply sets the pricing of every product from the given promotions, as returned by Compute.
+func Apply(products []*database.Product, promotions []*database.Promotion, now time.Time) {
+	sorted := sortByPriority(promotions)
+	for _, product := range products {
+		product.Pricing = compute(product, sorted, now)
+	}
+}
+
+// Compute returns the effective price of the product and the promotions applied to reach it.
+//
+// Promotions are considered by descending priority, then ascending ID. The first matching promotion always applies;
+// if it is not stackable no other promotion applies, otherwise the following stackable ones apply on the already
+// discounted price and non stackable ones are skipped. The effective price never goes below zero.
+func Compute(product *database.Product, promotions []*database.Promotion, now time.Time) *database.Pricing {
+	return compute(product, sortByPriority(promotions), now)
+}
+
+func compute(product *database.Product, sorted []*database.Promotion, now time.Time) *database.Pricing {
+	pricing := &database.Pricing{
+		ListPrice:         product.Price,
+		EffectivePrice:    product.Price,
+		AppliedPromotions: make([]*database.AppliedPromotion, 0),
+	}
+
+	for _, promotion := range sorted {
+		if pricing.EffectivePrice <= 0 {
+			break
+		}
+		if !IsActive(promotion, now) || !Matches(promotion, product) {
+			continue
+		}
+		if !promotion.Stackable && len(pricing.AppliedPromotions) > 0 {
+			continue
+		}
+
+		discount := roundCents(Discount(promotion, pricing.EffectivePrice))
+		pricing.EffectivePrice = roundCents(pricing.EffectivePrice - discount)
+		pricing.AppliedPromotions = append(pricing.AppliedPromotions, &database.AppliedPromotion{
+			ID:       promotion.ID,
+			Name:     promotion.Name,
+			Discount: discount,
+		})
+
+		if !promotion.Stackable {
+			break
+		}
+	}
+	return pricing
+}
+
+// IsActive reports whether the date window of the promotion contains now, StartsAt included and EndsAt excluded.
+func IsActive(promotion *database.Promotion, now time.Time) bool {
+	if promotion.StartsAt != nil && now.Before(*promotion.StartsAt) {
+		return false
+	}
+	if promotion.EndsAt != nil && !now.Before(*promotion.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Matches reports whether the promotion targets the product, by ID or by category.
+func Matches(promotion *database.Promotion, product *database.Product) bool {
+	for _, productId := range promotion.ProductIDs {
+		if productId == int64(product.ID) {
+			return true
+		}
+	}
+	if product.Category == "" {
+		return false
+	}
+	for _, category := range promotion.Categories {
+		if category == product.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns the amount the promotion takes off the price, never more than the price itself.
+func Discount(promotion *database.Promotion, price float64) float64 {
+	var discount float64
+	switch promotion.Kind {
+	case database.PromotionKindPercentage:
+		discount = price * promotion.Value / 100
+	case database.PromotionKindFixed:
+		discount = promotion.Value
+	}
+	return math.Min(math.Max(discount, 0), price)
+}
+
+func sortByPriority(promotions []*database.Promotion) []*database.Promotion {
+	sorted := make([]*database.Promotion, len(promotions))
+	copy(sorted, promotions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
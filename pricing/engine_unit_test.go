@@ -0,0 +1,143 @@
+// +build !integration
+
+package pricing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/pricing"
+)
+
+const (
+	productId       = 42
+	productPrice    = 100.0
+	productCategory = "t-shirts"
+)
+
+var now = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newProduct() *database.Product {
+	return &database.Product{ID: productId, Price: productPrice, Category: productCategory}
+}
+
+func TestCompute_Unit_NoPromotions(t *testing.T) {
+	result := pricing.Compute(newProduct(), nil, now)
+
+	assert.Equal(t, productPrice, result.ListPrice)
+	assert.Equal(t, productPrice, result.EffectivePrice)
+	assert.Empty(t, result.AppliedPromotions)
+}
+
+func TestCompute_Unit_Percentage(t *testing.T) {
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindPercentage, Value: 15, Categories: []string{productCategory}},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	assert.Equal(t, 85.0, result.EffectivePrice)
+	require.Len(t, result.AppliedPromotions, 1)
+	assert.Equal(t, 15.0, result.AppliedPromotions[0].Discount)
+}
+
+func TestCompute_Unit_FixedNeverBelowZero(t *testing.T) {
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindFixed, Value: 150, ProductIDs: []int64{productId}},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	assert.Equal(t, 0.0, result.EffectivePrice)
+	require.Len(t, result.AppliedPromotions, 1)
+	assert.Equal(t, productPrice, result.AppliedPromotions[0].Discount)
+}
+
+func TestCompute_Unit_NotMatching(t *testing.T) {
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindFixed, Value: 10, ProductIDs: []int64{productId + 1}},
+		{ID: 2, Kind: database.PromotionKindFixed, Value: 10, Categories: []string{"shoes"}},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	assert.Equal(t, productPrice, result.EffectivePrice)
+	assert.Empty(t, result.AppliedPromotions)
+}
+
+func TestCompute_Unit_DateWindow(t *testing.T) {
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindFixed, Value: 1, ProductIDs: []int64{productId}, StartsAt: &future},
+		{ID: 2, Kind: database.PromotionKindFixed, Value: 2, ProductIDs: []int64{productId}, EndsAt: &now},
+		{ID: 3, Kind: database.PromotionKindFixed, Value: 3, ProductIDs: []int64{productId}, StartsAt: &now, EndsAt: &future},
+		{ID: 4, Kind: database.PromotionKindFixed, Value: 4, ProductIDs: []int64{productId}, EndsAt: &past},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	assert.Equal(t, 97.0, result.EffectivePrice)
+	require.Len(t, result.AppliedPromotions, 1)
+	assert.Equal(t, 3, result.AppliedPromotions[0].ID)
+}
+
+func TestCompute_Unit_Stacking(t *testing.T) {
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindFixed, Value: 5, ProductIDs: []int64{productId}, Priority: 1, Stackable: true},
+		{ID: 2, Kind: database.PromotionKindPercentage, Value: 10, Categories: []string{productCategory}, Priority: 10, Stackable: true},
+		{ID: 3, Kind: database.PromotionKindPercentage, Value: 50, Categories: []string{productCategory}, Priority: 5},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	// 10% off 100, then 5 off 90, the non stackable promotion is skipped
+	assert.Equal(t, 85.0, result.EffectivePrice)
+	require.Len(t, result.AppliedPromotions, 2)
+	assert.Equal(t, 2, result.AppliedPromotions[0].ID)
+	assert.Equal(t, 1, result.AppliedPromotions[1].ID)
+}
+
+func TestCompute_Unit_NonStackableWins(t *testing.T) {
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindPercentage, Value: 10, Categories: []string{productCategory}, Priority: 1, Stackable: true},
+		{ID: 2, Kind: database.PromotionKindFixed, Value: 20, ProductIDs: []int64{productId}, Priority: 10},
+	}
+
+	result := pricing.Compute(newProduct(), promotions, now)
+
+	assert.Equal(t, 80.0, result.EffectivePrice)
+	require.Len(t, result.AppliedPromotions, 1)
+	assert.Equal(t, 2, result.AppliedPromotions[0].ID)
+}
+
+func TestCompute_Unit_Rounding(t *testing.T) {
+	product := &database.Product{ID: productId, Price: 19.99}
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindPercentage, Value: 33, ProductIDs: []int64{productId}},
+	}
+
+	result := pricing.Compute(product, promotions, now)
+
+	assert.Equal(t, 6.6, result.AppliedPromotions[0].Discount)
+	assert.Equal(t, 13.39, result.EffectivePrice)
+}
+
+func TestApply_Unit(t *testing.T) {
+	other := &database.Product{ID: productId + 1, Price: 10}
+	products := []*database.Product{newProduct(), other}
+	promotions := []*database.Promotion{
+		{ID: 1, Kind: database.PromotionKindFixed, Value: 10, ProductIDs: []int64{productId}},
+	}
+
+	pricing.Apply(products, promotions, now)
+
+	require.NotNil(t, products[0].Pricing)
+	assert.Equal(t, 90.0, products[0].Pricing.EffectivePrice)
+	require.NotNil(t, other.Pricing)
+	assert.Equal(t, 10.0, other.Pricing.EffectivePrice)
+}
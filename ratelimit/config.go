@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	backendEnvVar      = "RATELIMIT_BACKEND"  // available values: memory
+	defaultLimitEnvVar = "RATELIMIT_DEFAULT"  // limit of routes without their own, e.g. 50/s:100, empty for no limit
+	routeLimitsEnvVar  = "RATELIMIT_ROUTES"   // e.g. GET /products=10/s:20;DELETE /products/{id}=1/m
+	apiKeyLimitEnvVar  = "RATELIMIT_API_KEYS" // limit of API key lookups by IP address, empty for no limit
+
+	BackendMemory = "memory"
+
+	backendDefault      = BackendMemory
+	defaultLimitDefault = ""
+	routeLimitsDefault  = ""
+	apiKeyLimitDefault  = "20/s:40"
+)
+
+func loadConfig() *config {
+	logging.Log.Debug("Load rate limit configurations")
+	return &config{
+		backend:      utils.GetStringEnv(backendEnvVar, backendDefault),
+		defaultLimit: utils.GetStringEnv(defaultLimitEnvVar, defaultLimitDefault),
+		routeLimits:  utils.GetStringEnv(routeLimitsEnvVar, routeLimitsDefault),
+		apiKeyLimit:  utils.GetStringEnv(apiKeyLimitEnvVar, apiKeyLimitDefault),
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+// sweepInterval is how often full buckets are dropped, a full bucket behaves like a missing one
+const sweepInterval = time.Minute
+
+// New returns the limiter of the configured backend.
+func New() (Limiter, error) {
+	cfg := loadConfig()
+	switch cfg.backend {
+	case BackendMemory:
+		logging.Log.Info("Create new in-memory rate limiter")
+		return NewMemoryLimiter(), nil
+	default:
+		return nil, fmt.Errorf("rate limit backend %q not supported", cfg.backend)
+	}
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket)}
+}
+
+func (l *MemoryLimiter) Allow(key string, limit *Limit, now time.Time) *Result {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.sweep(now)
+
+	current, found := l.buckets[key]
+	if !found {
+		current = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[key] = current
+	}
+
+	elapsed := now.Sub(current.updatedAt).Seconds()
+	if elapsed > 0 {
+		current.tokens = math.Min(float64(limit.Burst), current.tokens+elapsed*limit.Rate)
+		current.updatedAt = now
+	}
+
+	result := &Result{Limit: limit.Burst}
+	if current.tokens >= 1 {
+		current.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - current.tokens) / limit.Rate)
+	}
+	result.Remaining = int(math.Floor(current.tokens))
+	result.Reset = secondsDuration((float64(limit.Burst) - current.tokens) / limit.Rate)
+	current.fullAt = now.Add(result.Reset)
+	return result
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < sweepInterval {
+		return
+	}
+	for key, current := range l.buckets {
+		if !now.Before(current.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweptAt = now
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
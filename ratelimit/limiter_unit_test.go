@@ -0,0 +1,129 @@
+// +build !integration
+
+package ratelimit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/ratelimit"
+)
+
+const clientKey = "GET /products|sub:user-1"
+
+func TestMemoryLimiter_Unit_Burst(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter()
+	limit := &ratelimit.Limit{Rate: 1, Burst: 3}
+	now := time.Now()
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		result := limiter.Allow(clientKey, limit, now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result := limiter.Allow(clientKey, limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// other keys have their own bucket
+	assert.True(t, limiter.Allow("GET /products|ip:10.0.0.1", limit, now).Allowed)
+}
+
+func TestMemoryLimiter_Unit_Refill(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter()
+	limit := &ratelimit.Limit{Rate: 2, Burst: 2}
+	now := time.Now()
+
+	assert.True(t, limiter.Allow(clientKey, limit, now).Allowed)
+	assert.True(t, limiter.Allow(clientKey, limit, now).Allowed)
+	assert.False(t, limiter.Allow(clientKey, limit, now).Allowed)
+
+	// half a second refills one token at 2 tokens per second
+	assert.True(t, limiter.Allow(clientKey, limit, now.Add(500*time.Millisecond)).Allowed)
+	assert.False(t, limiter.Allow(clientKey, limit, now.Add(500*time.Millisecond)).Allowed)
+
+	// the bucket never holds more than its burst
+	result := limiter.Allow(clientKey, limit, now.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryLimiter_Unit_Concurrent(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter()
+	limit := &ratelimit.Limit{Rate: 0.001, Burst: 50}
+	now := time.Now()
+
+	var allowed int
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if limiter.Allow(clientKey, limit, now).Allowed {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	assert.Equal(t, 50, allowed)
+}
+
+func TestParseLimit_Unit_Success(t *testing.T) {
+	tests := []struct {
+		limit    string
+		expected *ratelimit.Limit
+	}{
+		{"", nil},
+		{"10/s", &ratelimit.Limit{Rate: 10, Burst: 10}},
+		{"10/s:20", &ratelimit.Limit{Rate: 10, Burst: 20}},
+		{"120/m", &ratelimit.Limit{Rate: 2, Burst: 120}},
+		{" 3600/h:5 ", &ratelimit.Limit{Rate: 1, Burst: 5}},
+	}
+
+	for _, test := range tests {
+		limit, err := ratelimit.ParseLimit(test.limit)
+		require.NoError(t, err, test.limit)
+		assert.Equal(t, test.expected, limit, test.limit)
+	}
+}
+
+func TestParseLimit_Unit_Fail(t *testing.T) {
+	for _, limit := range []string{"10", "10/d", "0/s", "-1/s", "ten/s", "10/s:0", "10/s:x"} {
+		_, err := ratelimit.ParseLimit(limit)
+		assert.Error(t, err, limit)
+	}
+}
+
+func TestParseRules_Unit_Success(t *testing.T) {
+	rules, err := ratelimit.ParseRules("50/s:100", "get /products=10/s:20; DELETE /products/{id}=1/m")
+	require.NoError(t, err)
+
+	assert.Equal(t, &ratelimit.Limit{Rate: 10, Burst: 20}, rules.For("GET", "/products"))
+	assert.Equal(t, &ratelimit.Limit{Rate: 1.0 / 60, Burst: 1}, rules.For("DELETE", "/products/{id}"))
+	assert.Equal(t, &ratelimit.Limit{Rate: 50, Burst: 100}, rules.For("POST", "/products"))
+
+	unlimited, err := ratelimit.ParseRules("", "GET /products=10/s")
+	require.NoError(t, err)
+	assert.Nil(t, unlimited.For("POST", "/products"))
+}
+
+func TestParseRules_Unit_Fail(t *testing.T) {
+	for _, routes := range []string{"/products=10/s", "GET /products", "GET /products=10/x"} {
+		_, err := ratelimit.ParseRules("", routes)
+		assert.Error(t, err, routes)
+	}
+	_, err := ratelimit.ParseRules("10", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type config struct {
+	backend      string
+	defaultLimit string
+	routeLimits  string
+	apiKeyLimit  string
+}
+
+// Limiter keeps one token bucket per key. Implementations must be safe for concurrent use, the in-memory one only
+// limits a single instance, a shared store is needed to limit a whole deployment.
+type Limiter interface {
+	// Allow takes a token from the bucket of the key, if any left at the given time.
+	Allow(key string, limit *Limit, now time.Time) *Result
+}
+
+// Limit is a token bucket refilled with Rate tokens per second, up to Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again, RetryAfter the time until the next token if not Allowed
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Rules are the limits by route, routes are keyed by method and path template, e.g. "GET /products/{id}".
+type Rules struct {
+	defaultLimit *Limit
+	routes       map[string]*Limit
+	apiKeyLimit  *Limit
+}
+
+type MemoryLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket is refilled to its burst, from then on it can be forgotten
+	fullAt time.Time
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	burstSeparator = ":"
+	rateSeparator  = "/"
+)
+
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// LoadRules returns the limits configured by RATELIMIT_DEFAULT, RATELIMIT_ROUTES and RATELIMIT_API_KEYS.
+func LoadRules() (*Rules, error) {
+	cfg := loadConfig()
+	rules, err := ParseRules(cfg.defaultLimit, cfg.routeLimits)
+	if err != nil {
+		return nil, err
+	}
+	rules.apiKeyLimit, err = ParseLimit(cfg.apiKeyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("API key limit: %s", err.Error())
+	}
+	logging.SugaredLog.Infof("Rate limits: default %s, %d routes, API keys %s",
+		rules.defaultLimit, len(rules.routes), rules.apiKeyLimit)
+	return rules, nil
+}
+
+// ParseRules parses a default limit and a list of route limits like "GET /products=10/s:20;POST /products=1/s".
+// Empty limits mean no limit.
+func ParseRules(defaultLimit, routeLimits string) (*Rules, error) {
+	rules := &Rules{routes: make(map[string]*Limit)}
+
+	var parseErr error
+	rules.defaultLimit, parseErr = ParseLimit(defaultLimit)
+	if parseErr != nil {
+		return nil, fmt.Errorf("default limit: %s", parseErr.Error())
+	}
+
+	routes, routesErr := utils.ParseRouteValues(routeLimits)
+	if routesErr != nil {
+		return nil, routesErr
+	}
+	for route, routeLimit := range routes {
+		limit, limitErr := ParseLimit(routeLimit)
+		if limitErr != nil {
+			return nil, fmt.Errorf("route %s: %s", route, limitErr.Error())
+		}
+		rules.routes[route] = limit
+	}
+
+	return rules, nil
+}
+
+// ParseLimit parses a limit like "10/s:20", 10 requests per second with bursts of 20. Units are s, m and h, the burst
+// defaults to the number of requests. An empty limit means no limit and returns nil.
+func ParseLimit(limit string) (*Limit, error) {
+	limit = strings.TrimSpace(limit)
+	if limit == "" {
+		return nil, nil
+	}
+
+	rate := limit
+	burst := ""
+	if index := strings.Index(limit, burstSeparator); index >= 0 {
+		rate, burst = limit[:index], limit[index+1:]
+	}
+
+	parts := strings.SplitN(rate, rateSeparator, 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("limit %q not valid, expected requests/unit[:burst]", limit)
+	}
+	requests, requestsErr := strconv.Atoi(parts[0])
+	if requestsErr != nil || requests <= 0 {
+		return nil, fmt.Errorf("limit %q: requests must be a positive integer", limit)
+	}
+	unit, found := rateUnits[parts[1]]
+	if !found {
+		return nil, fmt.Errorf("limit %q: unit must be s, m or h", limit)
+	}
+
+	parsed := &Limit{Rate: float64(requests) / unit.Seconds(), Burst: requests}
+	if burst != "" {
+		var burstErr error
+		parsed.Burst, burstErr = strconv.Atoi(burst)
+		if burstErr != nil || parsed.Burst <= 0 {
+			return nil, fmt.Errorf("limit %q: burst must be a positive integer", limit)
+		}
+	}
+	return parsed, nil
+}
+
+// For returns the limit of the route, nil if it is not limited.
+func (r *Rules) For(method, pathTemplate string) *Limit {
+	if limit, found := r.routes[method+" "+pathTemplate]; found {
+		return limit
+	}
+	return r.defaultLimit
+}
+
+// ForApiKeys returns the limit of API key lookups by client, nil if they are not limited.
+func (r *Rules) ForApiKeys() *Limit {
+	return r.apiKeyLimit
+}
+
+func (l *Limit) String() string {
+	if l == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%g/s:%d", l.Rate, l.Burst)
+}
@@ -0,0 +1,186 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func (s *Server) getApiKeys(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-api-keys-handler")
+	defer span.Finish()
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Info("Get API keys")
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get API keys failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	apiKeys, err := database.GetApiKeys(tx, ctx)
+	if err != nil {
+		errMsg := "Get API keys failed: " + err.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("api-keys-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-keys-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("api-keys-found", len(apiKeys))
+	span.LogKV("api-keys-found", len(apiKeys))
+
+	sendJsonResponse(writer, http.StatusOK, apiKeys)
+}
+
+// createApiKey generates a key for the tenant of the request. The key is only part of this response, afterwards
+// nothing but its prefix can be retrieved.
+func (s *Server) createApiKey(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "create-api-key-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Create API key failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	var apiKey *database.ApiKey
+	unmarshErr := json.NewDecoder(request.Body).Decode(&apiKey)
+	if unmarshErr != nil || apiKey == nil {
+		errMsg := "Create API key failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("api-key-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-key-created", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	validateErr := apiKey.Validate()
+	if validateErr == nil {
+		validateErr = validateScopes(apiKey.Scopes)
+	}
+	if validateErr != nil {
+		errMsg := "Create API key failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("api-key-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-key-created", false, "error", errMsg)
+		return
+	}
+
+	if !s.authorize(writer, request, span, apiKeyCreateAction, apiKeyPolicyAttributes(apiKey)) {
+		span.SetTag("api-key-created", false)
+		span.LogKV("api-key-created", false)
+		return
+	}
+
+	key, prefix, salt, hash, generateErr := auth.GenerateApiKey()
+	if generateErr != nil {
+		errMsg := "Create API key failed: " + generateErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("api-key-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-key-created", false, "error", errMsg)
+		return
+	}
+	apiKey.Prefix = prefix
+	apiKey.Salt = salt
+	apiKey.Hash = hash
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create API key %s", apiKey.String())
+
+	createErr := database.CreateApiKey(tx, apiKey, ctx)
+	if createErr != nil {
+		errMsg := "Create API key failed: " + createErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("api-key-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-key-created", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("api-key", apiKey.String())
+	span.SetTag("api-key-created", true)
+	span.LogKV("api-key", apiKey.String(), "api-key-created", true)
+
+	sendJsonResponse(writer, http.StatusCreated, &createdApiKey{ApiKey: apiKey, Key: key})
+}
+
+func (s *Server) revokeApiKey(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "revoke-api-key-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Revoke API key failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	apiKeyId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("api-key-id", apiKeyId)
+
+	apiKey := &database.ApiKey{ID: apiKeyId}
+	if !s.authorize(writer, request, span, apiKeyRevokeAction, apiKeyPolicyAttributes(apiKey)) {
+		span.SetTag("api-key-revoked", false)
+		span.LogKV("api-key-revoked", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Revoke API key by ID: %d", apiKeyId)
+	revokeErr := database.RevokeApiKey(tx, apiKey, ctx)
+	if revokeErr != nil {
+		var errMsg string
+		switch revokeErr {
+		case sql.ErrNoRows:
+			errMsg = "Revoke API key failed: API key not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Revoke API key failed: " + revokeErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("api-key-revoked", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("api-key-revoked", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("api-key-revoked", true)
+	span.LogKV("api-key-revoked", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
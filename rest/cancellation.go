@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	// statusClientClosedRequest is the nginx convention for requests abandoned by their client
+	statusClientClosedRequest = 499
+
+	cancelReasonClientClosed = "client_closed"
+	cancelReasonDeadline     = "deadline"
+	cancelReasonShutdown     = "shutdown"
+)
+
+// deadlineMiddleware bounds the time spent serving the request, by route, see REST_ROUTE_TIMEOUTS.
+// Streaming routes have no deadline unless configured, their duration depends on the size of the content and on the
+// speed of the client.
+func (s *Server) deadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		timeout := s.routeTimeout(request.Method, routeTemplate(request))
+		if timeout <= 0 {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(request.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+func (s *Server) routeTimeout(method, pathTemplate string) time.Duration {
+	route := method + " " + pathTemplate
+	if timeout, found := s.routeTimeouts[route]; found {
+		return timeout
+	}
+	if streamingRoutes[route] {
+		return 0
+	}
+	return s.config.requestTimeout
+}
+
+// sendCancelledResponse answers a request whose queries were cancelled, see database.IsCancelled: 499 if the client
+// went away, 503 if the deadline was reached, the server is shutting down or PostgreSQL cancelled the statement.
+func (s *Server) sendCancelledResponse(writer http.ResponseWriter, request *http.Request) {
+	reason := cancelReasonDeadline
+	status := http.StatusServiceUnavailable
+	switch {
+	case s.baseCtx.Err() != nil:
+		reason = cancelReasonShutdown
+	case request.Context().Err() == context.Canceled:
+		reason = cancelReasonClientClosed
+		status = statusClientClosedRequest
+	}
+
+	route := request.Method + " " + routeTemplate(request)
+	logging.NamedFromContext(logging.RestLogger, request.Context()).Warnf("Request %s cancelled: %s", route, reason)
+	IncreaseCancelledRequests(route, reason)
+
+	sendProblemResponse(writer, status, "request cancelled: "+reason)
+}
+
+// parseRouteTimeouts parses per route timeouts like "GET /products=2s;POST /products/{id}/images=30s".
+func parseRouteTimeouts(routeTimeouts string) (map[string]time.Duration, error) {
+	values, parseErr := utils.ParseRouteValues(routeTimeouts)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	timeouts := make(map[string]time.Duration, len(values))
+	for route, value := range values {
+		timeout, durationErr := time.ParseDuration(value)
+		if durationErr != nil {
+			return nil, fmt.Errorf("route %s: timeout %q not valid", route, value)
+		}
+		timeouts[route] = timeout
+	}
+	return timeouts, nil
+}
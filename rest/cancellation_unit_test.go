@@ -0,0 +1,113 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const requestTimeoutKey = "REST_REQUEST_TIMEOUT"
+
+func TestDeadline_Unit_ClientClosed(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(getProductQuery).
+		WillDelayFor(time.Second).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	request := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products/%d", productId), nil).WithContext(ctx)
+	request.Header.Set("Authorization", "Bearer "+token(t, "products:read"))
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, 499, response.Code)
+	assert.Contains(t, response.Body.String(), "client_closed")
+}
+
+func TestDeadline_Unit_DeadlineExceeded(t *testing.T) {
+	require.NoError(t, os.Setenv(requestTimeoutKey, "20ms"))
+	defer os.Unsetenv(requestTimeoutKey)
+	server, mock := newServer(t, "")
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(getProductQuery).
+		WillDelayFor(time.Second).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodGet, fmt.Sprintf("/products/%d", productId), "products:read", "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Contains(t, response.Body.String(), "deadline")
+}
+
+func TestDeadline_Unit_StreamingRouteWithoutTimeout(t *testing.T) {
+	require.NoError(t, os.Setenv(requestTimeoutKey, "20ms"))
+	defer os.Unsetenv(requestTimeoutKey)
+	server, mock := newServer(t, "")
+
+	// the query outlasts the request timeout, which does not apply to image content
+	expectTenantTx(mock)
+	mock.ExpectQuery(getProductQuery).
+		WillDelayFor(100 * time.Millisecond).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodGet, fmt.Sprintf("/products/%d/images/1/content", productId),
+		"products:read", "")
+
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadline_Unit_StatementCancelled(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	// e.g. statement_timeout, the request context is still alive
+	expectTenantTx(mock)
+	mock.ExpectQuery(getProductQuery).
+		WillReturnError(&pq.Error{Code: "57014"})
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodGet, fmt.Sprintf("/products/%d/images/1/content", productId),
+		"products:read", "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Contains(t, response.Body.String(), "deadline")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadline_Unit_ApiKeyLookup(t *testing.T) {
+	require.NoError(t, os.Setenv(requestTimeoutKey, "20ms"))
+	defer os.Unsetenv(requestTimeoutKey)
+	server, mock := newServer(t, "")
+
+	mock.ExpectQuery(getApiKeyQuery).
+		WithArgs("prefix").
+		WillDelayFor(time.Second).
+		WillReturnError(sql.ErrNoRows)
+
+	request := httptest.NewRequest(http.MethodGet, "/products", nil)
+	request.Header.Set("X-API-Key", "prefix.secret")
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Contains(t, response.Body.String(), "deadline")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func newCatalogueCollector(db *sql.DB, interval, timeout time.Duration, priceBounds []float64) *catalogueCollector {
+	return &catalogueCollector{
+		db:          db,
+		interval:    interval,
+		timeout:     timeout,
+		priceBounds: priceBounds,
+		priceBands:  priceBandLabels(priceBounds),
+	}
+}
+
+// start refreshes the catalogue metrics periodically, so that scrapes never query the DB themselves.
+func (c *catalogueCollector) start() {
+	logging.Named(logging.RestLogger).Infof("Start catalogue metrics collector, interval %s", c.interval)
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		c.collectOnce()
+		for {
+			select {
+			case <-ticker.C:
+				c.collectOnce()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// shutdown stops the collector and waits for a running collection to complete, so the DB can be safely closed afterwards.
+func (c *catalogueCollector) shutdown() {
+	logging.Named(logging.RestLogger).Warn("Shutdown catalogue metrics collector")
+
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.stop = nil
+}
+
+func (c *catalogueCollector) collectOnce() {
+	span := opentracing.StartSpan("catalogue-metrics-collector")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	ctx, cancel := context.WithTimeout(opentracing.ContextWithSpan(context.Background(), span), c.timeout)
+	defer cancel()
+
+	// the collector connects as table owner, which row level security does not restrict, so it covers all tenants
+	stats, statsErr := database.GetCatalogueStats(c.db, c.priceBounds, ctx)
+	if statsErr != nil {
+		// the gauges keep their last values, their update time tells how stale they are
+		logging.Named(logging.RestLogger).Errorf("Collect catalogue metrics failed: %s", statsErr.Error())
+		span.SetTag("error", statsErr.Error())
+		span.LogKV("error", statsErr.Error())
+		return
+	}
+
+	SetCatalogueStats(stats, c.priceBands)
+	span.SetTag("products", stats.Products)
+	span.LogKV("products", stats.Products)
+}
+
+// parsePriceBounds parses the comma separated bounds of the price bands, e.g. "10,50,100", strictly ascending.
+func parsePriceBounds(priceBounds string) ([]float64, error) {
+	bounds := make([]float64, 0)
+	for _, value := range strings.Split(priceBounds, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		bound, parseErr := strconv.ParseFloat(value, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("price band bound %q not valid", value)
+		}
+		bounds = append(bounds, bound)
+	}
+	if !sort.Float64sAreSorted(bounds) {
+		return nil, fmt.Errorf("price band bounds %q not ascending", priceBounds)
+	}
+	for index := 1; index < len(bounds); index++ {
+		if bounds[index] == bounds[index-1] {
+			return nil, fmt.Errorf("price band bound %v repeated", bounds[index])
+		}
+	}
+	return bounds, nil
+}
+
+// priceBandLabels names the bands delimited by the bounds, e.g. "0-10", "10-50" and "50+" for 10 and 50.
+func priceBandLabels(priceBounds []float64) []string {
+	labels := make([]string, 0, len(priceBounds)+1)
+	lower := "0"
+	for _, bound := range priceBounds {
+		upper := strconv.FormatFloat(bound, 'f', -1, 64)
+		labels = append(labels, lower+"-"+upper)
+		lower = upper
+	}
+	return append(labels, lower+"+")
+}
@@ -0,0 +1,216 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func (s *Server) getCategorySchemas(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-category-schemas-handler")
+	defer span.Finish()
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Info("Get category schemas")
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get category schemas failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	schemas, err := database.GetCategorySchemas(tx, ctx)
+	if err != nil {
+		errMsg := "Get category schemas failed: " + err.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	span.SetTag("schemas-found", len(schemas))
+	span.LogKV("schemas-found", len(schemas))
+
+	sendJsonResponse(writer, http.StatusOK, schemas)
+}
+
+func (s *Server) getCategorySchema(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-category-schema-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get category schema failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	category := mux.Vars(request)["category"]
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get schema of category %s", category)
+	span.SetTag("category", category)
+
+	schema, err := database.GetCategorySchema(tx, category, ctx)
+	if err != nil {
+		var errMsg string
+		switch err {
+		case sql.ErrNoRows:
+			errMsg = "Get category schema failed: schema not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get category schema failed: " + err.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	sendJsonResponse(writer, http.StatusOK, &database.CategorySchema{Category: category, Schema: schema})
+}
+
+func (s *Server) putCategorySchema(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "put-category-schema-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Put category schema failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	category := mux.Vars(request)["category"]
+	span.SetTag("category", category)
+
+	var schema json.RawMessage
+	unmarshErr := json.NewDecoder(request.Body).Decode(&schema)
+	if unmarshErr != nil {
+		errMsg := "Put category schema failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	schemaErr := database.ValidateSchema(schema)
+	if schemaErr != nil {
+		errMsg := "Put category schema failed: invalid JSON Schema: " + schemaErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	if !s.authorize(writer, request, span, categoryUpdateAction, categoryPolicyAttributes(category)) {
+		span.SetTag("schema-updated", false)
+		span.LogKV("schema-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Put schema of category %s", category)
+
+	categorySchema := &database.CategorySchema{Category: category, Schema: schema}
+	upsertErr := database.UpsertCategorySchema(tx, categorySchema, ctx)
+	if upsertErr != nil {
+		errMsg := "Put category schema failed: " + upsertErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	span.SetTag("schema-updated", true)
+	span.LogKV("schema-updated", true)
+
+	sendJsonResponse(writer, http.StatusOK, categorySchema)
+}
+
+func (s *Server) deleteCategorySchema(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-category-schema-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete category schema failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	category := mux.Vars(request)["category"]
+	span.SetTag("category", category)
+
+	if !s.authorize(writer, request, span, categoryDeleteAction, categoryPolicyAttributes(category)) {
+		span.SetTag("schema-deleted", false)
+		span.LogKV("schema-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete schema of category %s", category)
+
+	deleteErr := database.DeleteCategorySchema(tx, category, ctx)
+	if deleteErr != nil {
+		errMsg := "Delete category schema failed: " + deleteErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	span.SetTag("schema-deleted", true)
+	span.LogKV("schema-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// validateProductAttributes checks the product attributes against the schema of its category, if any.
+// It returns the HTTP status code to answer with when the attributes cannot be accepted.
+func validateProductAttributes(db database.Querier, product *database.Product, ctx context.Context) (int, error) {
+	var schema json.RawMessage
+	if product.Category != "" {
+		var schemaErr error
+		schema, schemaErr = database.GetCategorySchema(db, product.Category, ctx)
+		if schemaErr != nil && schemaErr != sql.ErrNoRows {
+			return http.StatusInternalServerError, schemaErr
+		}
+	}
+
+	validateErr := database.ValidateAttributes(schema, product.Attributes)
+	if validateErr != nil {
+		return http.StatusBadRequest, validateErr
+	}
+	return http.StatusOK, nil
+}
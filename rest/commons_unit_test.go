@@ -0,0 +1,106 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/rest"
+)
+
+const (
+	hs256SecretKey = "AUTH_HS256_SECRET"
+	policyFileKey  = "POLICY_FILE"
+	apiKeyLimitKey = "RATELIMIT_API_KEYS"
+
+	hs256Secret = "test-secret"
+	subject     = "user-1"
+	tenant      = "acme"
+
+	setTenantRoleQuery = "SET LOCAL ROLE catalogue_tenant"
+	setTenantQuery     = "SELECT set_config\\('app.tenant_id', \\$1, true\\)"
+	getProductQuery    = "SELECT (.+) FROM products WHERE id = \\$1"
+	getVariantQuery    = "SELECT (.+) FROM product_variants WHERE id = \\$1 AND product_id = \\$2"
+	updateVariantQuery = "UPDATE product_variants SET (.+) WHERE id = \\$6 AND product_id = \\$7"
+	getPromotionQuery  = "SELECT (.+) FROM promotions WHERE id = \\$1"
+	getApiKeyQuery     = "SELECT (.+) FROM api_keys WHERE prefix = \\$1"
+
+	productId   = 1
+	variantId   = 2
+	promotionId = 3
+)
+
+var (
+	productColumns = []string{"name", "price", "status", "publish_at", "unpublish_at", "category", "attributes",
+		"rating_average", "rating_count"}
+	variantColumns   = []string{"sku", "name", "price", "stock", "attributes"}
+	promotionColumns = []string{"name", "kind", "value", "product_ids", "categories", "starts_at", "ends_at",
+		"priority", "stackable"}
+)
+
+// newServer returns a server on a database mock, accepting HS256 tokens and enforcing the given policy, if any
+func newServer(t *testing.T, policy string) (*rest.Server, sqlmock.Sqlmock) {
+	require.NoError(t, logging.InitGlobalLogger())
+
+	db, mock, mockErr := sqlmock.New()
+	require.NoError(t, mockErr)
+	t.Cleanup(func() { db.Close() })
+
+	if policy != "" {
+		path := filepath.Join(t.TempDir(), "policy.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(policy), 0600))
+		require.NoError(t, os.Setenv(policyFileKey, path))
+		defer os.Unsetenv(policyFileKey)
+	}
+	require.NoError(t, os.Setenv(hs256SecretKey, hs256Secret))
+	defer os.Unsetenv(hs256SecretKey)
+
+	server, serverErr := rest.NewTestServer(db)
+	require.NoError(t, serverErr)
+	return server, mock
+}
+
+// token signs a token of the test tenant with the given scopes
+func token(t *testing.T, scope string) string {
+	claims := jwt.MapClaims{
+		"sub":       subject,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"scope":     scope,
+		"tenant_id": tenant,
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(hs256Secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func expectTenantTx(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(setTenantRoleQuery).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(setTenantQuery).
+		WithArgs(tenant).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectProduct(mock sqlmock.Sqlmock, price float64, category string) {
+	mock.ExpectQuery(getProductQuery).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows(productColumns).
+			AddRow("t-shirt", price, "published", nil, nil, category, []byte(`{}`), 0, 0))
+}
+
+func expectVariant(mock sqlmock.Sqlmock, price interface{}) {
+	mock.ExpectQuery(getVariantQuery).
+		WithArgs(variantId, productId).
+		WillReturnRows(sqlmock.NewRows(variantColumns).
+			AddRow("TS-M", "M", price, 10, []byte(`{}`)))
+}
@@ -1,22 +1,73 @@
 package rest
 
 import (
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/database"
 	"github.com/bygui86/go-postgres-cicd/logging"
 	"github.com/bygui86/go-postgres-cicd/utils"
 )
 
 const (
-	restHostEnvVar = "REST_HOST"
-	restPortEnvVar = "REST_PORT"
+	restHostEnvVar                 = "REST_HOST"
+	restPortEnvVar                 = "REST_PORT"
+	schedulerIntervalEnvVar        = "REST_SCHEDULER_INTERVAL"          // duration, e.g. 30s
+	catalogueIntervalEnvVar        = "REST_CATALOGUE_METRICS_INTERVAL"  // duration, e.g. 1m
+	catalogueTimeoutEnvVar         = "REST_CATALOGUE_METRICS_TIMEOUT"   // duration, e.g. 10s
+	priceBandsEnvVar               = "REST_CATALOGUE_PRICE_BANDS"       // comma separated ascending bounds of the price bands
+	defaultLocaleEnvVar            = "REST_DEFAULT_LOCALE"              // locale of the untranslated product names
+	imageMaxSizeEnvVar             = "REST_IMAGE_MAX_SIZE"              // bytes
+	defaultTenantEnvVar            = "REST_DEFAULT_TENANT"              // tenant of requests without one, empty to require it
+	anonymousReadsEnvVar           = "REST_ANONYMOUS_READS"             // bool, whether GET requests without token can read public data
+	trustedProxiesEnvVar           = "REST_TRUSTED_PROXIES"             // comma separated IPs or CIDRs allowed to set X-Forwarded-For
+	requestTimeoutEnvVar           = "REST_REQUEST_TIMEOUT"             // duration, deadline of requests, 0 for none
+	routeTimeoutsEnvVar            = "REST_ROUTE_TIMEOUTS"              // e.g. GET /products=2s;POST /products/{id}/images=30s
+	dbPoolThresholdEnvVar          = "REST_DB_POOL_THRESHOLD"           // float between 0 and 1, share of connections in use degrading readiness
+	accessLogSampleRateEnvVar      = "REST_ACCESS_LOG_SAMPLE_RATE"      // float between 0 and 1, server errors are always logged
+	accessLogExcludedPathsEnvVar   = "REST_ACCESS_LOG_EXCLUDED_PATHS"   // comma separated paths or route templates
+	accessLogRedactedHeadersEnvVar = "REST_ACCESS_LOG_REDACTED_HEADERS" // comma separated
+
+	restHostDefault            = "0.0.0.0"
+	restPortDefault            = 8080
+	schedulerIntervalDefault   = 30 * time.Second
+	catalogueIntervalDefault   = time.Minute
+	catalogueTimeoutDefault    = 10 * time.Second
+	priceBandsDefault          = "10,50,100,500"
+	defaultLocaleDefault       = "en"
+	imageMaxSizeDefault        = 5 << 20
+	defaultTenantDefault       = database.DefaultTenant
+	anonymousReadsDefault      = true
+	trustedProxiesDefault      = ""
+	requestTimeoutDefault      = 10 * time.Second
+	routeTimeoutsDefault       = ""
+	dbPoolThresholdDefault     = 0.9
+	accessLogSampleRateDefault = 1.0
+)
 
-	restHostDefault = "0.0.0.0"
-	restPortDefault = 8080
+var (
+	accessLogExcludedPathsDefault   = []string{}
+	accessLogRedactedHeadersDefault = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
 )
 
 func loadConfig() *config {
-	logging.Log.Debug("Load REST configurations")
+	logging.Named(logging.RestLogger).Debug("Load REST configurations")
 	return &config{
-		restHost: utils.GetStringEnv(restHostEnvVar, restHostDefault),
-		restPort: utils.GetIntEnv(restPortEnvVar, restPortDefault),
+		restHost:                 utils.GetStringEnv(restHostEnvVar, restHostDefault),
+		restPort:                 utils.GetIntEnv(restPortEnvVar, restPortDefault),
+		schedulerInterval:        utils.GetDurationEnv(schedulerIntervalEnvVar, schedulerIntervalDefault),
+		catalogueInterval:        utils.GetDurationEnv(catalogueIntervalEnvVar, catalogueIntervalDefault),
+		catalogueTimeout:         utils.GetDurationEnv(catalogueTimeoutEnvVar, catalogueTimeoutDefault),
+		priceBands:               utils.GetStringEnv(priceBandsEnvVar, priceBandsDefault),
+		defaultLocale:            utils.GetStringEnv(defaultLocaleEnvVar, defaultLocaleDefault),
+		imageMaxSize:             utils.GetIntEnv(imageMaxSizeEnvVar, imageMaxSizeDefault),
+		defaultTenant:            utils.GetStringEnv(defaultTenantEnvVar, defaultTenantDefault),
+		anonymousReads:           utils.GetBoolEnv(anonymousReadsEnvVar, anonymousReadsDefault),
+		trustedProxies:           utils.GetStringEnv(trustedProxiesEnvVar, trustedProxiesDefault),
+		requestTimeout:           utils.GetDurationEnv(requestTimeoutEnvVar, requestTimeoutDefault),
+		routeTimeouts:            utils.GetStringEnv(routeTimeoutsEnvVar, routeTimeoutsDefault),
+		dbPoolThreshold:          utils.GetFloatEnv(dbPoolThresholdEnvVar, dbPoolThresholdDefault),
+		accessLogSampleRate:      utils.GetFloatEnv(accessLogSampleRateEnvVar, accessLogSampleRateDefault),
+		accessLogExcludedPaths:   utils.GetStringSliceEnv(accessLogExcludedPathsEnvVar, accessLogExcludedPathsDefault),
+		accessLogRedactedHeaders: utils.GetStringSliceEnv(accessLogRedactedHeadersEnvVar, accessLogRedactedHeadersDefault),
 	}
 }
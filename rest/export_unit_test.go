@@ -0,0 +1,72 @@
+// +build !integration
+
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+	"github.com/bygui86/go-postgres-cicd/policy"
+	"github.com/bygui86/go-postgres-cicd/ratelimit"
+)
+
+// NewTestServer builds a server on the given database like New, configured from the environment, without checking
+// the database nor starting the background jobs.
+func NewTestServer(db *sql.DB) (*Server, error) {
+	cfg := loadConfig()
+
+	verifier, verifierErr := auth.NewVerifier()
+	if verifierErr != nil {
+		return nil, verifierErr
+	}
+	policies, policiesErr := policy.New()
+	if policiesErr != nil {
+		return nil, policiesErr
+	}
+	limiter, limiterErr := ratelimit.New()
+	if limiterErr != nil {
+		return nil, limiterErr
+	}
+	rateLimits, rateLimitsErr := ratelimit.LoadRules()
+	if rateLimitsErr != nil {
+		return nil, rateLimitsErr
+	}
+	routeTimeouts, routeTimeoutsErr := parseRouteTimeouts(cfg.routeTimeouts)
+	if routeTimeoutsErr != nil {
+		return nil, routeTimeoutsErr
+	}
+	baseCtx, cancelBaseCtx := context.WithCancel(context.Background())
+
+	server := &Server{
+		config:        cfg,
+		db:            db,
+		verifier:      verifier,
+		policies:      policies,
+		limiter:       limiter,
+		rateLimits:    rateLimits,
+		routeTimeouts: routeTimeouts,
+		baseCtx:       baseCtx,
+		cancelBaseCtx: cancelBaseCtx,
+	}
+	server.setupRouter()
+	server.setupHTTPServer()
+	return server, nil
+}
+
+// Handler returns the handler of the HTTP server, with all its middlewares.
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Panics counts the panics recovered by route.
+var Panics = panics
+
+// HandleFunc adds a route to the router of the server, behind all its middlewares.
+func (s *Server) HandleFunc(path string, handler http.HandlerFunc) {
+	s.router.HandleFunc(path, handler)
+}
+
+// RestRequests counts the product requests served successfully, by handler name.
+var RestRequests = restRequests
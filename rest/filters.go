@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+const (
+	statusQueryParam = "status"
+	statusQueryAll   = "all"
+
+	attributeQueryParamPrefix = "attr."
+
+	searchQueryParam = "q"
+
+	sortQueryParam = "sort"
+	sortById       = "id"
+
+	embedQueryParam = "embed"
+	embedVariants   = "variants"
+)
+
+// productsFilterFromRequest builds the products filter from the query parameters.
+// Everybody sees published products, only the admin scope can ask for other statuses through the status parameter.
+// Parameters like attr.color=red filter on product attributes, sort=rating lists the best rated products first.
+func productsFilterFromRequest(request *http.Request) (*database.ProductsFilter, int, error) {
+	filter := &database.ProductsFilter{
+		Statuses:   []string{database.ProductStatusPublished},
+		Attributes: make(map[string]string),
+	}
+
+	switch sortParam := request.FormValue(sortQueryParam); sortParam {
+	case "", sortById:
+	case database.ProductsSortRating:
+		filter.Sort = database.ProductsSortRating
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("sort %q not valid", sortParam)
+	}
+
+	for key, values := range request.URL.Query() {
+		if !strings.HasPrefix(key, attributeQueryParamPrefix) || len(values) == 0 {
+			continue
+		}
+		attribute := strings.TrimPrefix(key, attributeQueryParamPrefix)
+		if attribute == "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("attribute name missing in %q", key)
+		}
+		filter.Attributes[attribute] = values[0]
+	}
+
+	statusParam := request.FormValue(statusQueryParam)
+	if statusParam == "" {
+		return filter, http.StatusOK, nil
+	}
+	if !hasScope(request, productsAdminScope) {
+		return nil, http.StatusForbidden, fmt.Errorf("status filter requires %s scope", productsAdminScope)
+	}
+	if statusParam == statusQueryAll {
+		filter.Statuses = nil
+		return filter, http.StatusOK, nil
+	}
+
+	filter.Statuses = strings.Split(statusParam, ",")
+	for _, status := range filter.Statuses {
+		if !database.IsValidProductStatus(status) {
+			return nil, http.StatusBadRequest, fmt.Errorf("status %q not valid", status)
+		}
+	}
+	return filter, http.StatusOK, nil
+}
+
+// isProductVisible reports whether the product can be shown to the request: only the admin scope sees unpublished ones.
+func isProductVisible(request *http.Request, product *database.Product) bool {
+	return product.Status == database.ProductStatusPublished || hasScope(request, productsAdminScope)
+}
@@ -19,12 +19,20 @@ func (s *Server) getProducts(writer http.ResponseWriter, request *http.Request)
 	span, ctx := retrieveSpanAndCtx(request, "get-products-handler")
 	defer span.Finish()
 
-	startTimer := time.Now()
-
-	logging.Log.Info("Get products")
+	logging.NamedFromContext(logging.RestLogger, ctx).Info("Get products")
 
 	span.SetTag("app", commons.ServiceName)
 
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get products failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
 	count, _ := strconv.Atoi(request.FormValue("count"))
 	start, _ := strconv.Atoi(request.FormValue("start"))
 	if count > 10 || count < 1 {
@@ -33,7 +41,30 @@ func (s *Server) getProducts(writer http.ResponseWriter, request *http.Request)
 	if start < 0 {
 		start = 0
 	}
-	products, err := database.GetProducts(s.db, start, count, ctx)
+	filter, filterCode, filterErr := productsFilterFromRequest(request)
+	if filterErr != nil {
+		errMsg := "Get products failed: " + filterErr.Error()
+		sendErrorResponse(writer, filterCode, errMsg)
+
+		span.SetTag("products-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("products-found", 0, "error", errMsg)
+		return
+	}
+	span.SetTag("statuses", filter.Statuses)
+
+	locales := s.localesFromRequest(request)
+	filter.Locales = locales
+	filter.Search = request.FormValue(searchQueryParam)
+	span.SetTag("locales", locales)
+
+	products, err := database.GetProducts(tx, start, count, filter, ctx)
+	if err == nil {
+		err = database.LocalizeProducts(tx, products, locales, ctx)
+	}
+	if err == nil {
+		err = priceProducts(tx, products, ctx)
+	}
 	if err != nil {
 		errMsg := "Get products failed: " + err.Error()
 		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
@@ -47,20 +78,26 @@ func (s *Server) getProducts(writer http.ResponseWriter, request *http.Request)
 	span.SetTag("products-found", len(products))
 	span.LogKV("products-found", len(products))
 
+	writer.Header().Add(varyHeaderKey, acceptLanguageHeaderKey)
 	sendJsonResponse(writer, http.StatusOK, products)
-
-	IncreaseRestRequests("getProducts")
-	ObserveRestRequestsTime("getProducts", float64(time.Now().Sub(startTimer).Milliseconds()))
 }
 
 func (s *Server) getProduct(writer http.ResponseWriter, request *http.Request) {
 	span, ctx := retrieveSpanAndCtx(request, "get-product-handler")
 	defer span.Finish()
 
-	startTimer := time.Now()
-
 	span.SetTag("app", commons.ServiceName)
 
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get product failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
 	vars := mux.Vars(request)
 	id, idErr := strconv.Atoi(vars["id"])
 	if idErr != nil {
@@ -72,12 +109,25 @@ func (s *Server) getProduct(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	logging.SugaredLog.Infof("Get product by ID: %d", id)
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get product by ID: %d", id)
 
 	span.SetTag("product-id", id)
 
 	product := &database.Product{ID: id}
-	getErr := database.GetProduct(s.db, product, ctx)
+	getErr := database.GetProduct(tx, product, ctx)
+	if getErr == nil && !isProductVisible(request, product) {
+		// unpublished products are hidden from the public as if they did not exist
+		getErr = sql.ErrNoRows
+	}
+	if getErr == nil && request.FormValue(embedQueryParam) == embedVariants {
+		product.Variants, getErr = database.GetVariants(tx, id, ctx)
+	}
+	if getErr == nil {
+		getErr = database.LocalizeProducts(tx, []*database.Product{product}, s.localesFromRequest(request), ctx)
+	}
+	if getErr == nil {
+		getErr = priceProducts(tx, []*database.Product{product}, ctx)
+	}
 	if getErr != nil {
 		var errMsg string
 		switch getErr {
@@ -98,23 +148,29 @@ func (s *Server) getProduct(writer http.ResponseWriter, request *http.Request) {
 	span.SetTag("product-found", true)
 	span.LogKV("product-id", id, "product-found", true)
 
+	s.setLanguageHeaders(writer, product.Locale)
 	sendJsonResponse(writer, http.StatusOK, product)
-
-	IncreaseRestRequests("getProduct")
-	ObserveRestRequestsTime("getProduct", float64(time.Now().Sub(startTimer).Milliseconds()))
 }
 
 func (s *Server) createProduct(writer http.ResponseWriter, request *http.Request) {
 	span, ctx := retrieveSpanAndCtx(request, "create-product-handler")
 	defer span.Finish()
 
-	startTimer := time.Now()
-
 	span.SetTag("app", commons.ServiceName)
 
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Create product failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
 	var product *database.Product
 	unmarshErr := json.NewDecoder(request.Body).Decode(&product)
-	if unmarshErr != nil {
+	if unmarshErr != nil || product == nil {
 		errMsg := "Create product failed: invalid request payload"
 		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
 
@@ -125,9 +181,37 @@ func (s *Server) createProduct(writer http.ResponseWriter, request *http.Request
 	}
 	defer request.Body.Close()
 
-	logging.SugaredLog.Infof("Create product %s", product.String())
+	lifecycleErr := product.PrepareLifecycle(time.Now())
+	if lifecycleErr != nil {
+		errMsg := "Create product failed: " + lifecycleErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
 
-	createErr := database.CreateProduct(s.db, product, ctx)
+		span.SetTag("product-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-created", false, "error", errMsg)
+		return
+	}
+
+	attributesCode, attributesErr := validateProductAttributes(tx, product, ctx)
+	if attributesErr != nil {
+		errMsg := "Create product failed: " + attributesErr.Error()
+		sendErrorResponse(writer, attributesCode, errMsg)
+
+		span.SetTag("product-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-created", false, "error", errMsg)
+		return
+	}
+
+	if !s.authorize(writer, request, span, productCreateAction, productPolicyAttributes(product)) {
+		span.SetTag("product-created", false)
+		span.LogKV("product-created", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create product %s", product.String())
+
+	createErr := database.CreateProduct(tx, product, ctx)
 	if createErr != nil {
 		errMsg := "Create product failed: " + createErr.Error()
 		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
@@ -142,20 +226,28 @@ func (s *Server) createProduct(writer http.ResponseWriter, request *http.Request
 	span.SetTag("product-created", true)
 	span.LogKV("product", product.String(), "product-created", true)
 
-	sendJsonResponse(writer, http.StatusCreated, product)
+	// the product only counts once the transaction is committed
+	afterCommit(request, IncreaseProductsCreated)
 
-	IncreaseRestRequests("createProduct")
-	ObserveRestRequestsTime("createProduct", float64(time.Now().Sub(startTimer).Milliseconds()))
+	sendJsonResponse(writer, http.StatusCreated, product)
 }
 
 func (s *Server) updateProduct(writer http.ResponseWriter, request *http.Request) {
 	span, ctx := retrieveSpanAndCtx(request, "update-product-handler")
 	defer span.Finish()
 
-	startTimer := time.Now()
-
 	span.SetTag("app", commons.ServiceName)
 
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Update product failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
 	vars := mux.Vars(request)
 	id, idErr := strconv.Atoi(vars["id"])
 	if idErr != nil {
@@ -170,7 +262,7 @@ func (s *Server) updateProduct(writer http.ResponseWriter, request *http.Request
 
 	var product *database.Product
 	unmarshErr := json.NewDecoder(request.Body).Decode(&product)
-	if unmarshErr != nil {
+	if unmarshErr != nil || product == nil {
 		errMsg := "Update product failed: invalid request payload"
 		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
 
@@ -182,10 +274,60 @@ func (s *Server) updateProduct(writer http.ResponseWriter, request *http.Request
 	defer request.Body.Close()
 
 	product.ID = id
-	logging.SugaredLog.Infof("Update product: %s", product.String())
+
+	// the current version fills in the status left out by the update, and policy conditions may compare the
+	// product with it, e.g. to bound price changes. It is locked so that concurrent updates are checked in turn.
+	current := &database.Product{ID: id}
+	currentErr := database.GetProductForUpdate(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Update product failed: product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Update product failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("product-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-updated", false, "error", errMsg)
+		return
+	}
+
+	lifecycleErr := product.PrepareUpdateLifecycle(current)
+	if lifecycleErr != nil {
+		errMsg := "Update product failed: " + lifecycleErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("product-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-updated", false, "error", errMsg)
+		return
+	}
+
+	attributesCode, attributesErr := validateProductAttributes(tx, product, ctx)
+	if attributesErr != nil {
+		errMsg := "Update product failed: " + attributesErr.Error()
+		sendErrorResponse(writer, attributesCode, errMsg)
+
+		span.SetTag("product-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-updated", false, "error", errMsg)
+		return
+	}
+
+	if !s.authorize(writer, request, span, productUpdateAction, productUpdatePolicyAttributes(current, product)) {
+		span.SetTag("product-updated", false)
+		span.LogKV("product-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Update product: %s", product.String())
 	span.SetTag("product-id", id)
 
-	updateErr := database.UpdateProduct(s.db, product, ctx)
+	updateErr := database.UpdateProduct(tx, product, ctx)
 	if updateErr != nil {
 		errMsg := "Update product failed: " + updateErr.Error()
 		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
@@ -200,20 +342,27 @@ func (s *Server) updateProduct(writer http.ResponseWriter, request *http.Request
 	span.SetTag("product-updated", true)
 	span.LogKV("product", product.String(), "product-updated", true)
 
-	sendJsonResponse(writer, http.StatusOK, product)
+	afterCommit(request, IncreaseProductsUpdated)
 
-	IncreaseRestRequests("updateProduct")
-	ObserveRestRequestsTime("updateProduct", float64(time.Now().Sub(startTimer).Milliseconds()))
+	sendJsonResponse(writer, http.StatusOK, product)
 }
 
 func (s *Server) deleteProduct(writer http.ResponseWriter, request *http.Request) {
 	span, ctx := retrieveSpanAndCtx(request, "delete-product-handler")
 	defer span.Finish()
 
-	startTimer := time.Now()
-
 	span.SetTag("app", commons.ServiceName)
 
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete product failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
 	vars := mux.Vars(request)
 	id, idErr := strconv.Atoi(vars["id"])
 	if idErr != nil {
@@ -226,10 +375,39 @@ func (s *Server) deleteProduct(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
-	logging.SugaredLog.Infof("Delete product by ID: %d", id)
 	span.SetTag("product-id", id)
 
-	deleteErr := database.DeleteProduct(s.db, id, ctx)
+	current := &database.Product{ID: id}
+	currentErr := database.GetProduct(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete product failed: product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete product failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("product-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("product-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, productDeleteAction, productPolicyAttributes(current)) {
+		span.SetTag("product-deleted", false)
+		span.LogKV("product-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete product by ID: %d", id)
+
+	// image metadata is removed by the cascading delete, so their keys are needed beforehand to remove the content
+	imageKeys, deleteErr := database.GetImageKeys(tx, id, ctx)
+	if deleteErr == nil {
+		deleteErr = database.DeleteProduct(tx, id, ctx)
+	}
 	if deleteErr != nil {
 		errMsg := "Delete product failed: " + deleteErr.Error()
 		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
@@ -240,11 +418,13 @@ func (s *Server) deleteProduct(writer http.ResponseWriter, request *http.Request
 		return
 	}
 
+	// the metadata is only gone once the transaction is committed
+	afterCommit(request, func() { s.deleteBlobs(imageKeys, ctx) })
+
 	span.SetTag("product-deleted", true)
 	span.LogKV("product-deleted", true)
 
-	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+	afterCommit(request, IncreaseProductsDeleted)
 
-	IncreaseRestRequests("deleteProduct")
-	ObserveRestRequestsTime("deleteProduct", float64(time.Now().Sub(startTimer).Milliseconds()))
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
 }
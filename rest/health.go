@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"context"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/health"
+)
+
+// RegisterHealthChecks adds the checks of the dependencies of the server to the registry: the database must be
+// reachable and migrated for the server to be ready, a saturated pool only degrades it.
+func (s *Server) RegisterHealthChecks(registry *health.Registry) {
+	registry.Register("db-connection", true, func(ctx context.Context) error {
+		return database.CheckConnection(s.db, ctx)
+	}, health.Readiness)
+
+	registry.Register("db-pool", false, func(ctx context.Context) error {
+		return database.CheckPoolSaturation(s.db, s.config.dbPoolThreshold)
+	}, health.Readiness)
+
+	registry.Register("db-migrations", true, func(ctx context.Context) error {
+		return database.CheckMigrations(s.db, ctx)
+	}, health.Startup)
+}
@@ -0,0 +1,564 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/storage"
+)
+
+const (
+	imageFormField = "image"
+	// sniffLength is the number of bytes http.DetectContentType looks at
+	sniffLength = 512
+	// multipartOverhead leaves room for boundaries and part headers on top of the image size limit
+	multipartOverhead = 64 << 10
+
+	etagHeaderKey          = "ETag"
+	ifNoneMatchHeaderKey   = "If-None-Match"
+	contentLengthHeaderKey = "Content-Length"
+)
+
+var allowedImageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imageDigest computes checksum and size of the content written to it.
+type imageDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+func (d *imageDigest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+func (s *Server) getImages(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-images-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get images failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get images of product %d", productId)
+	span.SetTag("product-id", productId)
+
+	getErr := checkProductVisible(tx, request, productId, ctx)
+	var images []*database.Image
+	if getErr == nil {
+		images, getErr = database.GetImages(tx, productId, ctx)
+	}
+	if getErr != nil {
+		var errMsg string
+		switch getErr {
+		case sql.ErrNoRows:
+			errMsg = "Get images failed: product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get images failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("images-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("images-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("images-found", len(images))
+	span.LogKV("images-found", len(images))
+
+	sendJsonResponse(writer, http.StatusOK, images)
+}
+
+func (s *Server) getImageContent(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-image-content-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	productId, imageId := imageIdsFromRequest(request)
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get content of image %d of product %d", imageId, productId)
+	span.SetTag("product-id", productId)
+	span.SetTag("image-id", imageId)
+
+	// the transaction ends before the content is sent, so that slow clients don't hold a connection of the pool
+	image := &database.Image{ID: imageId, ProductID: productId}
+	getErr := s.runInTenantTx(request, ctx, func(tx database.Querier) error {
+		visibleErr := checkProductVisible(tx, request, productId, ctx)
+		if visibleErr != nil {
+			return visibleErr
+		}
+		return database.GetImage(tx, image, ctx)
+	})
+	var content io.ReadCloser
+	if getErr == nil {
+		content, getErr = s.blobStore.Get(ctx, image.StorageKey)
+	}
+	if getErr != nil {
+		var errMsg string
+		switch {
+		case getErr == sql.ErrNoRows, getErr == storage.ErrBlobNotFound:
+			errMsg = "Get image content failed: image not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		case database.IsCancelled(getErr):
+			// streaming routes are not answered by tenancyMiddleware when cancelled
+			errMsg = "Get image content failed: " + getErr.Error()
+			s.sendCancelledResponse(writer, request)
+		default:
+			errMsg = "Get image content failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-found", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-found", false, "error", errMsg)
+		return
+	}
+	defer content.Close()
+
+	span.SetTag("image-found", true)
+	span.LogKV("image-found", true)
+
+	// the checksum identifies the content, which never changes under the same storage key
+	etag := strconv.Quote(image.Checksum)
+	writer.Header().Set(etagHeaderKey, etag)
+	if request.Header.Get(ifNoneMatchHeaderKey) == etag {
+		writer.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writer.Header().Set(contentTypeHeaderKey, image.ContentType)
+	writer.Header().Set(contentLengthHeaderKey, strconv.FormatInt(image.Size, 10))
+	writer.WriteHeader(http.StatusOK)
+	_, copyErr := io.Copy(writer, content)
+	if copyErr != nil {
+		logging.NamedFromContext(logging.RestLogger, ctx).Errorf("Error sending image content: %s", copyErr.Error())
+	}
+}
+
+func (s *Server) uploadImage(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "upload-image-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("product-id", productId)
+
+	// the upload is streamed to the blob store between two transactions, so that it holds no connection of the pool
+	var product *database.Product
+	productCode := http.StatusOK
+	productErr := s.runInTenantTx(request, ctx, func(tx database.Querier) error {
+		var err error
+		product, productCode, err = policyTargetProduct(tx, productId, ctx)
+		return err
+	})
+	if productErr != nil {
+		if productCode == http.StatusOK {
+			// the transaction itself failed
+			productCode = http.StatusInternalServerError
+		}
+		errMsg := "Upload image failed: " + productErr.Error()
+		if database.IsCancelled(productErr) {
+			s.sendCancelledResponse(writer, request)
+		} else {
+			sendErrorResponse(writer, productCode, errMsg)
+		}
+
+		span.SetTag("image-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-created", false, "error", errMsg)
+		return
+	}
+	// the content is only known once uploaded, so the policy sees the attributes of the product alone
+	if !s.authorize(writer, request, span, imageCreateAction, productResourcePolicyAttributes(product, map[string]interface{}{})) {
+		span.SetTag("image-created", false)
+		span.LogKV("image-created", false)
+		return
+	}
+
+	request.Body = http.MaxBytesReader(writer, request.Body, int64(s.config.imageMaxSize)+multipartOverhead)
+	defer request.Body.Close()
+
+	part, partErr := imagePartFromRequest(request)
+	if partErr != nil {
+		errMsg := "Upload image failed: " + partErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("image-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-created", false, "error", errMsg)
+		return
+	}
+	defer part.Close()
+
+	image, status, storeErr := s.storeImage(productId, part, ctx)
+	if storeErr != nil {
+		errMsg := "Upload image failed: " + storeErr.Error()
+		sendErrorResponse(writer, status, errMsg)
+
+		span.SetTag("image-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-created", false, "error", errMsg)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create image %s", image.String())
+
+	createErr := s.runInTenantTx(request, ctx, func(tx database.Querier) error {
+		return database.CreateImage(tx, image, ctx)
+	})
+	if createErr != nil {
+		s.deleteBlobs([]string{image.StorageKey}, ctx)
+
+		errMsg := "Upload image failed: "
+		switch {
+		case database.IsForeignKeyViolation(createErr):
+			errMsg += "product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		case database.IsCancelled(createErr):
+			errMsg += createErr.Error()
+			s.sendCancelledResponse(writer, request)
+		default:
+			errMsg += createErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-created", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("image", image.String())
+	span.SetTag("image-created", true)
+	span.LogKV("image", image.String(), "image-created", true)
+
+	sendJsonResponse(writer, http.StatusCreated, image)
+}
+
+func (s *Server) updateImage(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "update-image-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Update image failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, imageId := imageIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("image-id", imageId)
+
+	var image *database.Image
+	unmarshErr := json.NewDecoder(request.Body).Decode(&image)
+	if unmarshErr != nil || image == nil || image.Position < 0 {
+		errMsg := "Update image failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("image-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-updated", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	image.ID = imageId
+	image.ProductID = productId
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Update image failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("image-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-updated", false, "error", errMsg)
+		return
+	}
+	current := &database.Image{ID: imageId, ProductID: productId}
+	currentErr := database.GetImage(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Update image failed: image not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Update image failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-updated", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, imageUpdateAction, imageUpdatePolicyAttributes(product, current, image)) {
+		span.SetTag("image-updated", false)
+		span.LogKV("image-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Update image %s", image.String())
+
+	updateErr := database.UpdateImage(tx, image, ctx)
+	if updateErr == nil {
+		updateErr = database.GetImage(tx, image, ctx)
+	}
+	if updateErr != nil {
+		errMsg := "Update image failed: "
+		switch {
+		case updateErr == sql.ErrNoRows:
+			errMsg += "image not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		case database.IsUniqueViolation(updateErr):
+			errMsg += "primary image changed concurrently"
+			sendErrorResponse(writer, http.StatusConflict, errMsg)
+		default:
+			errMsg += updateErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-updated", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("image", image.String())
+	span.SetTag("image-updated", true)
+	span.LogKV("image", image.String(), "image-updated", true)
+
+	sendJsonResponse(writer, http.StatusOK, image)
+}
+
+func (s *Server) deleteImage(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-image-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete image failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, imageId := imageIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("image-id", imageId)
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Delete image failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("image-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-deleted", false, "error", errMsg)
+		return
+	}
+	current := &database.Image{ID: imageId, ProductID: productId}
+	currentErr := database.GetImage(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete image failed: image not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete image failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, imageDeleteAction, imagePolicyAttributes(product, current)) {
+		span.SetTag("image-deleted", false)
+		span.LogKV("image-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete image %d of product %d", imageId, productId)
+
+	storageKey, deleteErr := database.DeleteImage(tx, productId, imageId, ctx)
+	if deleteErr != nil {
+		var errMsg string
+		switch deleteErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete image failed: image not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete image failed: " + deleteErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("image-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("image-deleted", false, "error", errMsg)
+		return
+	}
+
+	// the metadata is only gone once the transaction is committed
+	afterCommit(request, func() { s.deleteBlobs([]string{storageKey}, ctx) })
+
+	span.SetTag("image-deleted", true)
+	span.LogKV("image-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// checkProductVisible returns sql.ErrNoRows if the product does not exist or is hidden to the request.
+func checkProductVisible(db database.Querier, request *http.Request, productId int, ctx context.Context) error {
+	product := &database.Product{ID: productId}
+	getErr := database.GetProduct(db, product, ctx)
+	if getErr == nil && !isProductVisible(request, product) {
+		return sql.ErrNoRows
+	}
+	return getErr
+}
+
+// imagePartFromRequest streams the multipart body up to the image field, without buffering the other parts.
+func imagePartFromRequest(request *http.Request) (*multipart.Part, error) {
+	reader, readerErr := request.MultipartReader()
+	if readerErr != nil {
+		return nil, readerErr
+	}
+	for {
+		part, partErr := reader.NextPart()
+		if partErr == io.EOF {
+			return nil, fmt.Errorf("form field %q missing", imageFormField)
+		}
+		if partErr != nil {
+			return nil, partErr
+		}
+		if part.FormName() == imageFormField {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+// storeImage sniffs the content type of the part and writes it to the blob store, computing checksum and size.
+// On error it returns the HTTP status to answer with, and nothing is left in the blob store.
+func (s *Server) storeImage(productId int, part *multipart.Part, ctx context.Context) (*database.Image, int, error) {
+	head := make([]byte, sniffLength)
+	headLength, headErr := io.ReadFull(part, head)
+	if headErr != nil && headErr != io.ErrUnexpectedEOF {
+		if headErr == io.EOF {
+			return nil, http.StatusBadRequest, fmt.Errorf("image empty")
+		}
+		return nil, http.StatusBadRequest, headErr
+	}
+	head = head[:headLength]
+
+	contentType := http.DetectContentType(head)
+	if !allowedImageContentTypes[contentType] {
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("content type %s not allowed", contentType)
+	}
+
+	storageKey, keyErr := newImageStorageKey(productId)
+	if keyErr != nil {
+		return nil, http.StatusInternalServerError, keyErr
+	}
+
+	maxSize := int64(s.config.imageMaxSize)
+	digest := &imageDigest{hash: sha256.New()}
+	// reading one byte more than allowed is enough to know the limit is exceeded
+	content := io.TeeReader(io.LimitReader(io.MultiReader(bytes.NewReader(head), part), maxSize+1), digest)
+
+	putErr := s.blobStore.Put(ctx, storageKey, content)
+	if putErr != nil {
+		s.deleteBlobs([]string{storageKey}, ctx)
+		return nil, http.StatusInternalServerError, putErr
+	}
+	if digest.size > maxSize {
+		s.deleteBlobs([]string{storageKey}, ctx)
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("image larger than %d bytes", maxSize)
+	}
+
+	return &database.Image{
+		ProductID:   productId,
+		StorageKey:  storageKey,
+		FileName:    part.FileName(),
+		ContentType: contentType,
+		Size:        digest.size,
+		Checksum:    hex.EncodeToString(digest.hash.Sum(nil)),
+	}, http.StatusCreated, nil
+}
+
+// deleteBlobs removes the content of deleted images, even if the request is cancelled. Failures only leave orphaned
+// blobs behind, so they are logged.
+func (s *Server) deleteBlobs(keys []string, ctx context.Context) {
+	ctx = detachedContext{Context: ctx}
+	for _, key := range keys {
+		deleteErr := s.blobStore.Delete(ctx, key)
+		if deleteErr != nil {
+			logging.NamedFromContext(logging.RestLogger, ctx).Warnf("Delete image content %s failed: %s", key, deleteErr.Error())
+		}
+	}
+}
+
+// newImageStorageKey returns a random key, so that a replaced image never serves stale cached content.
+func newImageStorageKey(productId int) (string, error) {
+	random := make([]byte, 16)
+	_, randErr := rand.Read(random)
+	if randErr != nil {
+		return "", randErr
+	}
+	return fmt.Sprintf("products/%d/%s", productId, hex.EncodeToString(random)), nil
+}
+
+// imageIdsFromRequest returns the product and image IDs, already validated as numbers by the route patterns.
+func imageIdsFromRequest(request *http.Request) (int, int) {
+	vars := mux.Vars(request)
+	productId, _ := strconv.Atoi(vars["id"])
+	imageId, _ := strconv.Atoi(vars["imageId"])
+	return productId, imageId
+}
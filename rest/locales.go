@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+)
+
+const (
+	acceptLanguageHeaderKey  = "Accept-Language"
+	contentLanguageHeaderKey = "Content-Language"
+	varyHeaderKey            = "Vary"
+)
+
+type languageRange struct {
+	locale  string
+	quality float64
+}
+
+// localesFromRequest returns the fallback chain of locales negotiated through Accept-Language, e.g. for
+// "de-CH, fr;q=0.8" it returns de-CH, de, fr and finally the default locale.
+func (s *Server) localesFromRequest(request *http.Request) []string {
+	ranges := parseAcceptLanguage(request.Header.Get(acceptLanguageHeaderKey))
+
+	locales := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			locales = append(locales, locale)
+		}
+	}
+
+	for _, languageRange := range ranges {
+		// lookup fallback, progressively truncating the tag: zh-Hant-TW, zh-Hant, zh
+		subtags := strings.Split(languageRange.locale, "-")
+		for i := len(subtags); i > 0; i-- {
+			add(strings.Join(subtags[:i], "-"))
+		}
+	}
+	add(s.config.defaultLocale)
+	return locales
+}
+
+// parseAcceptLanguage returns the valid language ranges of the header, most preferred first.
+// Wildcards and ranges with zero or malformed quality are ignored.
+func parseAcceptLanguage(header string) []*languageRange {
+	ranges := make([]*languageRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+
+		locale, localeErr := database.NormalizeLocale(strings.TrimSpace(fields[0]))
+		if localeErr != nil {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var qualityErr error
+				quality, qualityErr = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if qualityErr != nil {
+					quality = 0
+				}
+			}
+		}
+		if quality <= 0 || quality > 1 {
+			continue
+		}
+
+		ranges = append(ranges, &languageRange{locale: locale, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
+
+// setLanguageHeaders tells caches that the response depends on Accept-Language and which language it is in.
+func (s *Server) setLanguageHeaders(writer http.ResponseWriter, locale string) {
+	writer.Header().Add(varyHeaderKey, acceptLanguageHeaderKey)
+	if locale == "" {
+		locale = s.config.defaultLocale
+	}
+	writer.Header().Set(contentLanguageHeaderKey, locale)
+}
@@ -1,34 +1,244 @@
 package rest
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/tracing"
+)
 
 const (
-	namespace = "gotraces"
-	subsystem = "httpserver"
+	namespace          = "gotraces"
+	subsystem          = "httpserver"
+	catalogueSubsystem = "catalogue"
 )
 
+// legacyRequestMethods label the deprecated restRequests and restRequestsTiming series by route, with the name of the
+// handler they were recorded by
+var legacyRequestMethods = map[string]string{
+	http.MethodGet + " " + rootProductsEndpoint:                                               "getProducts",
+	http.MethodGet + " " + pathVariableRegexp.ReplaceAllString(productsIdEndpoint, "{$1}"):    "getProduct",
+	http.MethodPost + " " + rootProductsEndpoint:                                              "createProduct",
+	http.MethodPut + " " + pathVariableRegexp.ReplaceAllString(productsIdEndpoint, "{$1}"):    "updateProduct",
+	http.MethodDelete + " " + pathVariableRegexp.ReplaceAllString(productsIdEndpoint, "{$1}"): "deleteProduct",
+}
+
+// sizeBuckets go from 100 bytes to 100 megabytes, image uploads included
+var sizeBuckets = prometheus.ExponentialBuckets(100, 10, 7)
+
 var (
+	requests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of REST requests served, by route, method and status class",
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	requestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_errors_total",
+			Help:      "Number of REST requests failed with a server error, by route, method and status class",
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of REST requests in seconds, by route, method and status class",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	inFlightRequests = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "in_flight_requests",
+			Help:      "Number of REST requests being served",
+		},
+	)
+
+	requestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_size_bytes",
+			Help:      "Size of REST request bodies in bytes, by route and method",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"route", "method"},
+	)
+
+	responseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "response_size_bytes",
+			Help:      "Size of REST response bodies in bytes, by route, method and status class",
+			Buckets:   sizeBuckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	// Deprecated: replaced by requests, kept for one release for the dashboards and alerts still relying on it
 	restRequests = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "rest_requests_total",
-			Help:      "Number of REST requests managed",
+			Help:      "Deprecated, see requests_total: number of REST requests managed",
 		},
 		[]string{"method"},
 	)
 
+	// Deprecated: replaced by requestDuration, kept for one release for the dashboards and alerts still relying on it
 	restRequestsTiming = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: namespace,
 			Subsystem: subsystem,
 			Name:      "rest_requests_execution_time_milliseconds",
-			Help:      "Execution time of REST requests in milliseconds",
+			Help:      "Deprecated, see request_duration_seconds: execution time of REST requests in milliseconds",
 			Buckets:   []float64{1e-10, 1e-8, 1e-6, 1e-4, 1e-2, 0.025, 0.05, 0.075, 0.1, 0.125, 0.25, 0.5, 1, 1.5, 2, 2.5, 5, 7.5, 10, 25, 50, 100, 250, 500, 750, 1000, 2500, 5000, 10000},
 		},
 		[]string{"method"},
 	)
 
+	reviewsSubmitted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "reviews_submitted_total",
+			Help:      "Number of product reviews submitted",
+		},
+	)
+
+	reviewsModerated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "reviews_moderated_total",
+			Help:      "Number of product reviews moderated, by resulting status",
+		},
+		[]string{"status"},
+	)
+
+	rateLimitDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "rate_limit_decisions_total",
+			Help:      "Number of rate limiter decisions, by route and decision",
+		},
+		[]string{"route", "decision"},
+	)
+
+	cancelledRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "cancelled_requests_total",
+			Help:      "Number of REST requests cancelled before completion, by route and reason",
+		},
+		[]string{"route", "reason"},
+	)
+
+	panics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "panics_total",
+			Help:      "Number of panics recovered while serving REST requests, by route",
+		},
+		[]string{"route"},
+	)
+
+	products = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "products",
+			Help:      "Number of products of all tenants",
+		},
+	)
+
+	productsByPriceBand = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "products_by_price_band",
+			Help:      "Number of products of all tenants, by price band",
+		},
+		[]string{"band"},
+	)
+
+	averagePrice = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "price_average",
+			Help:      "Average price of the products of all tenants",
+		},
+	)
+
+	medianPrice = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "price_median",
+			Help:      "Median price of the products of all tenants",
+		},
+	)
+
+	catalogueUpdated = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "stats_updated_timestamp_seconds",
+			Help:      "Time of the last successful collection of the catalogue gauges",
+		},
+	)
+
+	productsCreated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "products_created_total",
+			Help:      "Number of products created",
+		},
+	)
+
+	productsUpdated = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "products_updated_total",
+			Help:      "Number of products updated",
+		},
+	)
+
+	productsDeleted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: catalogueSubsystem,
+			Name:      "products_deleted_total",
+			Help:      "Number of products deleted",
+		},
+	)
+
 	// customSummary = prometheus.NewSummaryVec(
 	// 	prometheus.SummaryOpts{
 	// 		Namespace:   "",
@@ -55,17 +265,103 @@ var (
 	// )
 )
 
-func RegisterCustomMetrics() {
-	prometheus.MustRegister(
+// DbMetrics returns the collectors of the metrics of the DB of the server, the primary one.
+func (s *Server) DbMetrics() []prometheus.Collector {
+	return database.Metrics(map[string]*sql.DB{database.RolePrimary: s.db})
+}
+
+func RegisterCustomMetrics() error {
+	collectors := []prometheus.Collector{
+		requests,
+		requestErrors,
+		requestDuration,
+		inFlightRequests,
+		requestSize,
+		responseSize,
 		restRequests,
 		restRequestsTiming,
-	)
+		reviewsSubmitted,
+		reviewsModerated,
+		rateLimitDecisions,
+		cancelledRequests,
+		panics,
+		products,
+		productsByPriceBand,
+		averagePrice,
+		medianPrice,
+		catalogueUpdated,
+		productsCreated,
+		productsUpdated,
+		productsDeleted,
+	}
+	for _, collector := range collectors {
+		err := prometheus.Register(collector)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObserveRequest records a served request in the RED metrics: rate, errors and duration, the latter with the trace
+// of the request as exemplar.
+func ObserveRequest(route, method string, status int, duration time.Duration, requestBytes, responseBytes int64,
+	traceId string) {
+	statusClass := fmt.Sprintf("%dxx", status/100)
+	requests.WithLabelValues(route, method, statusClass).Inc()
+	if status >= http.StatusInternalServerError {
+		requestErrors.WithLabelValues(route, method, statusClass).Inc()
+	}
+	tracing.ObserveWithTraceID(requestDuration.WithLabelValues(route, method, statusClass), duration.Seconds(), traceId)
+	requestSize.WithLabelValues(route, method).Observe(float64(requestBytes))
+	responseSize.WithLabelValues(route, method, statusClass).Observe(float64(responseBytes))
+
+	// the deprecated series only counted the product requests served successfully
+	if legacyMethod, found := legacyRequestMethods[method+" "+route]; found && status < http.StatusBadRequest {
+		restRequests.WithLabelValues(legacyMethod).Inc()
+		restRequestsTiming.WithLabelValues(legacyMethod).Observe(float64(duration.Milliseconds()))
+	}
+}
+
+func IncreaseReviewsSubmitted() {
+	reviewsSubmitted.Inc()
+}
+
+func IncreaseReviewsModerated(status string) {
+	reviewsModerated.WithLabelValues(status).Inc()
+}
+
+func IncreaseRateLimitDecisions(route, decision string) {
+	rateLimitDecisions.WithLabelValues(route, decision).Inc()
+}
+
+func IncreaseCancelledRequests(route, reason string) {
+	cancelledRequests.WithLabelValues(route, reason).Inc()
+}
+
+func IncreasePanics(route string) {
+	panics.WithLabelValues(route).Inc()
+}
+
+// SetCatalogueStats updates the catalogue gauges, the price bands being labelled in the order of the stats.
+func SetCatalogueStats(stats *database.CatalogueStats, priceBands []string) {
+	products.Set(float64(stats.Products))
+	for index, count := range stats.PriceBands {
+		productsByPriceBand.WithLabelValues(priceBands[index]).Set(float64(count))
+	}
+	averagePrice.Set(stats.AveragePrice)
+	medianPrice.Set(stats.MedianPrice)
+	catalogueUpdated.SetToCurrentTime()
+}
+
+func IncreaseProductsCreated() {
+	productsCreated.Inc()
 }
 
-func IncreaseRestRequests(method string) {
-	go restRequests.WithLabelValues(method).Inc()
+func IncreaseProductsUpdated() {
+	productsUpdated.Inc()
 }
 
-func ObserveRestRequestsTime(method string, timing float64) {
-	restRequestsTiming.WithLabelValues(method).Observe(timing)
+func IncreaseProductsDeleted() {
+	productsDeleted.Inc()
 }
@@ -0,0 +1,46 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bygui86/go-postgres-cicd/rest"
+)
+
+func TestObserveRequest_Unit_DeprecatedSeries(t *testing.T) {
+	server, mock := newServer(t, "")
+	getProduct := rest.RestRequests.WithLabelValues("getProduct")
+	before := testutil.ToFloat64(getProduct)
+
+	expectTenantTx(mock)
+	expectProduct(mock, 20, "t-shirts")
+	mock.ExpectQuery("SELECT (.+) FROM product_translations").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "locale", "name", "description"}))
+	mock.ExpectQuery("SELECT (.+) FROM promotions").
+		WillReturnRows(sqlmock.NewRows(promotionColumns))
+	mock.ExpectCommit()
+
+	response := sendRequest(t, server.Handler(), http.MethodGet, fmt.Sprintf("/products/%d", productId), "products:read", "")
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(getProduct))
+
+	// failed requests were never counted
+	expectTenantTx(mock)
+	mock.ExpectQuery(getProductQuery).
+		WillReturnError(fmt.Errorf("error"))
+	mock.ExpectRollback()
+
+	response = sendRequest(t, server.Handler(), http.MethodGet, fmt.Sprintf("/products/%d", productId), "products:read", "")
+
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(getProduct))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,30 +1,297 @@
 package rest
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	mathrand "math/rand"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+	"github.com/bygui86/go-postgres-cicd/database"
 	"github.com/bygui86/go-postgres-cicd/logging"
 )
 
-func requestInfoPrintingMiddleware(next http.Handler) http.Handler {
+const (
+	authorizationHeaderKey   = "Authorization"
+	wwwAuthenticateHeaderKey = "WWW-Authenticate"
+	bearerPrefix             = "Bearer "
+	apiKeyHeaderKey          = "X-API-Key"
+	// apiKeySubjectPrefix tells API key subjects apart from token subjects in logs and audit trails
+	apiKeySubjectPrefix = "apikey:"
+	requestIdHeaderKey  = "X-Request-ID"
+
+	redactedValue  = "[REDACTED]"
+	unmatchedRoute = "unmatched"
+)
+
+// requestIdRegexp matches the request IDs accepted from clients, others are replaced so that they cannot forge
+// log lines or grow them without bounds
+var requestIdRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type accessLogContextKey struct{}
+
+// requestIdMiddleware gives every request an ID, taken from the X-Request-ID header if valid or generated otherwise.
+// The ID is stored in the context for logging.FromContext and echoed in the response.
+func requestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requestId := request.Header.Get(requestIdHeaderKey)
+		if !requestIdRegexp.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+		writer.Header().Set(requestIdHeaderKey, requestId)
+
+		next.ServeHTTP(writer, request.WithContext(logging.WithRequestId(request.Context(), requestId)))
+	})
+}
+
+// accessLogMiddleware writes one structured entry per request once the response is sent. Requests are sampled
+// by the configured rate, except server errors, and excluded paths are never logged.
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		start := time.Now()
+
+		entry := &accessLogEntry{}
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+		ctx := context.WithValue(request.Context(), accessLogContextKey{}, entry)
+		next.ServeHTTP(recorder, request.WithContext(ctx))
+
+		route := s.matchedRouteTemplate(request)
+		if s.excludedFromAccessLog(request.URL.Path, route) {
+			return
+		}
+		if recorder.status < http.StatusInternalServerError && mathrand.Float64() >= s.config.accessLogSampleRate {
+			return
+		}
+
+		logging.Named(logging.RestLogger).Desugar().Info("access",
+			zap.String("method", request.Method),
+			zap.String("route", route),
+			zap.String("path", request.URL.Path),
+			zap.Int("status", recorder.status),
+			zap.Int64("bytes", recorder.bytes),
+			zap.Duration("latency", time.Since(start)),
+			zap.String(logging.RequestIdKey, logging.RequestId(request.Context())),
+			zap.String("trace_id", entry.traceId),
+			zap.String("user", entry.user),
+			zap.String("client_ip", s.clientIP(request)),
+			zap.Object("headers", s.redactedHeaders(request.Header)),
+		)
+	})
+}
+
+// metricsMiddleware records the RED metrics of every request, the route being its template to bound the cardinality.
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		logging.SugaredLog.Infof("RequestURI: %s", request.RequestURI)
-		logging.SugaredLog.Infof("URL: %s", request.URL)
-		logging.SugaredLog.Infof("x-forwarded-for: %s", request.Header.Get("X-FORWARDED-FOR"))
-		logging.SugaredLog.Infof("RemoteAddr: %s", request.RemoteAddr)
+		start := time.Now()
+		inFlightRequests.Inc()
+		defer inFlightRequests.Dec()
 
-		printHeaders(request)
+		body := &countingReadCloser{ReadCloser: request.Body}
+		request.Body = body
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+		next.ServeHTTP(recorder, request)
 
-		next.ServeHTTP(writer, request)
-		// all remaining handlers on the pipeline will execute before reaching this line
+		ObserveRequest(s.matchedRouteTemplate(request), request.Method, recorder.status, time.Since(start),
+			body.bytes, recorder.bytes, accessLogExemplarTraceId(request))
 	})
 }
 
-func printHeaders(r *http.Request) {
-	logging.Log.Info("Headers:")
-	for name, values := range r.Header {
-		for _, value := range values {
-			logging.SugaredLog.Infof("\t%s: %s", name, value)
+// matchedRouteTemplate returns the template of the route matching the request, the request seen by the access log
+// middleware wraps the router so the route is not part of its context.
+func (s *Server) matchedRouteTemplate(request *http.Request) string {
+	var match mux.RouteMatch
+	if !s.router.Match(request, &match) || match.Route == nil {
+		return unmatchedRoute
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return pathVariableRegexp.ReplaceAllString(template, "{$1}")
+}
+
+func (s *Server) excludedFromAccessLog(path, route string) bool {
+	for _, excluded := range s.config.accessLogExcludedPaths {
+		if excluded == path || excluded == route {
+			return true
 		}
 	}
+	return false
+}
+
+// redactedHeaders marshals the headers for the access log, hiding the values of the sensitive ones.
+func (s *Server) redactedHeaders(headers http.Header) zapcore.ObjectMarshaler {
+	return zapcore.ObjectMarshalerFunc(func(encoder zapcore.ObjectEncoder) error {
+		for name, values := range headers {
+			value := strings.Join(values, ", ")
+			for _, redacted := range s.config.accessLogRedactedHeaders {
+				if http.CanonicalHeaderKey(redacted) == name {
+					value = redactedValue
+					break
+				}
+			}
+			encoder.AddString(name, value)
+		}
+		return nil
+	})
+}
+
+func setAccessLogUser(request *http.Request, user string) {
+	if entry, ok := request.Context().Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		entry.user = user
+	}
+}
+
+// accessLogTraceId returns the trace ID of the span of the request, empty if none.
+func accessLogTraceId(request *http.Request) string {
+	if entry, ok := request.Context().Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		return entry.traceId
+	}
+	return ""
+}
+
+// accessLogExemplarTraceId returns the trace ID of the span of the request if sampled, empty otherwise.
+func accessLogExemplarTraceId(request *http.Request) string {
+	if entry, ok := request.Context().Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		return entry.exemplarTraceId
+	}
+	return ""
+}
+
+func setAccessLogTraceId(request *http.Request, traceId, exemplarTraceId string) {
+	if entry, ok := request.Context().Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		entry.traceId = traceId
+		entry.exemplarTraceId = exemplarTraceId
+	}
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(content []byte) (int, error) {
+	r.wroteHeader = true
+	written, err := r.ResponseWriter.Write(content)
+	r.bytes += int64(written)
+	return written, err
+}
+
+// apiKeyAuthenticationMiddleware authenticates requests carrying an X-API-Key header, as an alternative to bearer
+// tokens. The principal of a key is bound to the tenant that created it and holds the scopes granted to it.
+func (s *Server) apiKeyAuthenticationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		key := request.Header.Get(apiKeyHeaderKey)
+		if key == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if principalFromRequest(request) != nil {
+			sendProblemResponse(writer, http.StatusUnauthorized, "bearer token and API key are mutually exclusive")
+			return
+		}
+
+		prefix, secret, splitErr := auth.SplitApiKey(key)
+		if splitErr != nil {
+			sendProblemResponse(writer, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		if !s.allowApiKeyLookup(writer, request) {
+			return
+		}
+
+		// keys are looked up outside of tenant transactions, their tenant is not known yet
+		apiKey, getErr := database.GetApiKeyByPrefix(s.db, prefix, request.Context())
+		if getErr != nil && (request.Context().Err() != nil || database.IsCancelled(getErr)) {
+			s.sendCancelledResponse(writer, request)
+			return
+		}
+		if getErr != nil && getErr != sql.ErrNoRows {
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Errorf("Get API key %s failed: %s", prefix, getErr.Error())
+			sendProblemResponse(writer, http.StatusInternalServerError, "API key verification failed")
+			return
+		}
+		now := time.Now()
+		if getErr == sql.ErrNoRows || !auth.VerifyApiKeySecret(apiKey.Salt, apiKey.Hash, secret) ||
+			!apiKey.IsActive(now) {
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Debugf("API key %s rejected", prefix)
+			sendProblemResponse(writer, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		// last_used_at is only written when stale, not on every request
+		if apiKey.IsUsageStale(now) {
+			touchErr := database.TouchApiKey(s.db, apiKey.ID, request.Context())
+			if touchErr != nil {
+				logging.NamedFromContext(logging.RestLogger, request.Context()).Errorf("Touch API key %s failed: %s", prefix, touchErr.Error())
+			}
+		}
+
+		// names are not unique, the prefix is: it keeps keys apart in rate limits and audit trails
+		principal := &auth.Principal{Subject: apiKeySubjectPrefix + apiKey.Prefix, Scopes: apiKey.Scopes, Tenant: apiKey.TenantID}
+		setAccessLogUser(request, principal.Subject)
+		ctx := context.WithValue(request.Context(), principalContextKey{}, principal)
+		ctx = logging.WithUser(ctx, principal.Subject)
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+// authenticationMiddleware verifies the bearer token of the request, if any, and stores its principal in the context.
+// Requests without token go on anonymous, route scopes are then enforced by scopeMiddleware.
+func (s *Server) authenticationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		header := request.Header.Get(authorizationHeaderKey)
+		if header == "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if !strings.HasPrefix(header, bearerPrefix) {
+			writer.Header().Set(wwwAuthenticateHeaderKey, `Bearer error="invalid_request"`)
+			sendProblemResponse(writer, http.StatusUnauthorized, "bearer token expected")
+			return
+		}
+
+		principal, verifyErr := s.verifier.Verify(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+		if verifyErr != nil {
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Debugf("Token verification failed: %s", verifyErr.Error())
+			writer.Header().Set(wwwAuthenticateHeaderKey, `Bearer error="invalid_token"`)
+			sendProblemResponse(writer, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		setAccessLogUser(request, principal.Subject)
+		ctx := context.WithValue(request.Context(), principalContextKey{}, principal)
+		ctx = logging.WithUser(ctx, principal.Subject)
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
+
+func (r *countingReadCloser) Read(content []byte) (int, error) {
+	read, err := r.ReadCloser.Read(content)
+	r.bytes += int64(read)
+	return read, err
 }
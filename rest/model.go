@@ -1,10 +1,21 @@
 package rest
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"io"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/policy"
+	"github.com/bygui86/go-postgres-cicd/ratelimit"
+	"github.com/bygui86/go-postgres-cicd/storage"
 )
 
 type Server struct {
@@ -12,10 +23,113 @@ type Server struct {
 	router     *mux.Router
 	httpServer *http.Server
 	db         *sql.DB
-	running    bool
+	scheduler  *scheduler
+	catalogue  *catalogueCollector
+	blobStore  storage.BlobStore
+	verifier   *auth.Verifier
+	policies   *policy.Engine
+	limiter    ratelimit.Limiter
+	rateLimits *ratelimit.Rules
+	// trustedProxies are the networks whose X-Forwarded-For header is trusted to name the client
+	trustedProxies []*net.IPNet
+	// routeTimeouts override the request timeout by "METHOD /path" key
+	routeTimeouts map[string]time.Duration
+	// baseCtx is the parent of all request contexts, it is cancelled to abort the requests still running at shutdown
+	baseCtx       context.Context
+	cancelBaseCtx context.CancelFunc
+	running       bool
 }
 
 type config struct {
-	restHost string
-	restPort int
+	restHost                 string
+	restPort                 int
+	schedulerInterval        time.Duration
+	catalogueInterval        time.Duration
+	catalogueTimeout         time.Duration
+	priceBands               string
+	defaultLocale            string
+	imageMaxSize             int
+	defaultTenant            string
+	anonymousReads           bool
+	trustedProxies           string
+	requestTimeout           time.Duration
+	routeTimeouts            string
+	dbPoolThreshold          float64
+	accessLogSampleRate      float64
+	accessLogExcludedPaths   []string
+	accessLogRedactedHeaders []string
+}
+
+// problem is an RFC 7807 error response.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// RequestId lets clients quote the request when reporting the problem
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// bufferedResponseWriter holds the response back until the transaction of the request is committed,
+// so that a failed commit can still be answered with an error.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// commitHooks are the side effects of a handler waiting for the transaction of the request to be committed.
+type commitHooks struct {
+	hooks []func()
+}
+
+// detachedContext keeps the values of its parent, e.g. span and request ID, but not its cancellation, for the
+// cleanups that must complete even if the request is cancelled.
+type detachedContext struct {
+	context.Context
+}
+
+type catalogueCollector struct {
+	db          *sql.DB
+	interval    time.Duration
+	timeout     time.Duration
+	priceBounds []float64
+	// priceBands are the labels of the bands delimited by priceBounds
+	priceBands []string
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+type scheduler struct {
+	db       *sql.DB
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// createdApiKey is the response to an API key creation, the only one holding the key itself.
+type createdApiKey struct {
+	*database.ApiKey
+	Key string `json:"key"`
+}
+
+// accessLogEntry collects the fields of the access log known only to inner handlers and middlewares.
+type accessLogEntry struct {
+	traceId         string
+	exemplarTraceId string
+	user            string
+}
+
+// countingReadCloser counts the bytes read from a request body
+type countingReadCloser struct {
+	io.ReadCloser
+	bytes int64
+}
+
+// statusRecorder records the status and the size of the response for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
 }
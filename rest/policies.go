@@ -0,0 +1,231 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/policy"
+)
+
+const (
+	productCreateAction = "product:create"
+	productUpdateAction = "product:update"
+	productDeleteAction = "product:delete"
+
+	// the actions on the sub-resources of a product share its prefix, so that "product:*" covers them too
+	variantCreateAction     = "product:variant:create"
+	variantUpdateAction     = "product:variant:update"
+	variantDeleteAction     = "product:variant:delete"
+	imageCreateAction       = "product:image:create"
+	imageUpdateAction       = "product:image:update"
+	imageDeleteAction       = "product:image:delete"
+	translationUpdateAction = "product:translation:update"
+	translationDeleteAction = "product:translation:delete"
+	reviewCreateAction      = "product:review:create"
+	reviewModerateAction    = "product:review:moderate"
+	reviewDeleteAction      = "product:review:delete"
+
+	promotionCreateAction = "promotion:create"
+	promotionUpdateAction = "promotion:update"
+	promotionDeleteAction = "promotion:delete"
+	categoryUpdateAction  = "category:update"
+	categoryDeleteAction  = "category:delete"
+	apiKeyCreateAction    = "apikey:create"
+	apiKeyRevokeAction    = "apikey:revoke"
+
+	anonymousSubject = "anonymous"
+
+	currentAttributePrefix = "current_"
+)
+
+// authorize evaluates the action of the request principal against the policy, given the attributes of the resource
+// it targets. The decision is logged and recorded in the span, a denied action is answered with 403.
+func (s *Server) authorize(writer http.ResponseWriter, request *http.Request, span opentracing.Span,
+	action string, attributes map[string]interface{}) bool {
+
+	subject := &policy.Subject{ID: anonymousSubject}
+	if principal := principalFromRequest(request); principal != nil {
+		subject = &policy.Subject{ID: principal.Subject, Roles: principal.Roles, Scopes: principal.Scopes}
+	}
+
+	decision := s.policies.Evaluate(subject, action, attributes)
+	logging.NamedFromContext(logging.RestLogger, opentracing.ContextWithSpan(request.Context(), span)).
+		Infof("Policy decision: %s", decision.String())
+	span.SetTag("policy-allowed", decision.Allowed)
+	span.LogKV("policy-action", decision.Action, "policy-allowed", decision.Allowed,
+		"policy-role", decision.Role, "policy-reason", decision.Reason)
+
+	if !decision.Allowed {
+		sendProblemResponse(writer, http.StatusForbidden, action+" denied: "+decision.Reason)
+	}
+	return decision.Allowed
+}
+
+// productPolicyAttributes exposes the product to policy conditions, numbers as float64 like JSON numbers.
+func productPolicyAttributes(product *database.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       float64(product.ID),
+		"name":     product.Name,
+		"price":    product.Price,
+		"status":   product.Status,
+		"category": product.Category,
+	}
+}
+
+// productUpdatePolicyAttributes adds to the attributes of the updated product the ones of its current version,
+// prefixed with "current_", and the relative price change in percent.
+func productUpdatePolicyAttributes(current, updated *database.Product) map[string]interface{} {
+	attributes := withCurrentAttributes(productPolicyAttributes(updated), productPolicyAttributes(current))
+	attributes["price_change_percent"] = priceChangePercent(current.Price, updated.Price)
+	return attributes
+}
+
+// policyTargetProduct loads the product whose sub-resource is targeted by the request, with the status to answer
+// with when it can't.
+func policyTargetProduct(tx database.Querier, productId int, ctx context.Context) (*database.Product, int, error) {
+	product := &database.Product{ID: productId}
+	getErr := database.GetProduct(tx, product, ctx)
+	switch {
+	case getErr == sql.ErrNoRows:
+		return nil, http.StatusNotFound, errors.New("product not found")
+	case getErr != nil:
+		return nil, http.StatusInternalServerError, getErr
+	}
+	return product, http.StatusOK, nil
+}
+
+// productResourcePolicyAttributes adds to the attributes of a sub-resource the ID, category and status of its
+// product, the latter as "product_status".
+func productResourcePolicyAttributes(product *database.Product, attributes map[string]interface{}) map[string]interface{} {
+	attributes["product_id"] = float64(product.ID)
+	attributes["category"] = product.Category
+	attributes["product_status"] = product.Status
+	return attributes
+}
+
+// variantPolicyAttributes exposes the variant with its effective price, the product one if it has none.
+// On creation the price change is relative to the product price.
+func variantPolicyAttributes(product *database.Product, variant *database.Variant) map[string]interface{} {
+	price := variantPrice(product, variant)
+	return productResourcePolicyAttributes(product, map[string]interface{}{
+		"id":                   float64(variant.ID),
+		"sku":                  variant.SKU,
+		"name":                 variant.Name,
+		"price":                price,
+		"stock":                float64(variant.Stock),
+		"price_change_percent": priceChangePercent(product.Price, price),
+	})
+}
+
+// variantUpdatePolicyAttributes is like productUpdatePolicyAttributes, between the effective prices of the variant.
+func variantUpdatePolicyAttributes(product *database.Product, current, updated *database.Variant) map[string]interface{} {
+	currentAttributes := variantPolicyAttributes(product, current)
+	delete(currentAttributes, "price_change_percent")
+	attributes := withCurrentAttributes(variantPolicyAttributes(product, updated), currentAttributes)
+	attributes["price_change_percent"] = priceChangePercent(variantPrice(product, current), variantPrice(product, updated))
+	return attributes
+}
+
+func variantPrice(product *database.Product, variant *database.Variant) float64 {
+	if variant.Price != nil {
+		return *variant.Price
+	}
+	return product.Price
+}
+
+func imagePolicyAttributes(product *database.Product, image *database.Image) map[string]interface{} {
+	return productResourcePolicyAttributes(product, map[string]interface{}{
+		"id":           float64(image.ID),
+		"content_type": image.ContentType,
+		"size":         float64(image.Size),
+		"position":     float64(image.Position),
+		"primary":      image.Primary,
+	})
+}
+
+// imageUpdatePolicyAttributes exposes the current image with the position and primary flag of the update, the
+// current ones prefixed with "current_".
+func imageUpdatePolicyAttributes(product *database.Product, current, updated *database.Image) map[string]interface{} {
+	attributes := imagePolicyAttributes(product, current)
+	attributes["position"] = float64(updated.Position)
+	attributes["primary"] = updated.Primary
+	attributes[currentAttributePrefix+"position"] = float64(current.Position)
+	attributes[currentAttributePrefix+"primary"] = current.Primary
+	return attributes
+}
+
+func translationPolicyAttributes(product *database.Product, locale string) map[string]interface{} {
+	return productResourcePolicyAttributes(product, map[string]interface{}{"locale": locale})
+}
+
+func reviewPolicyAttributes(product *database.Product, review *database.Review) map[string]interface{} {
+	return productResourcePolicyAttributes(product, map[string]interface{}{
+		"id":     float64(review.ID),
+		"author": review.Author,
+		"rating": float64(review.Rating),
+		"status": review.Status,
+	})
+}
+
+// reviewModerationPolicyAttributes adds the current status of the review, e.g. to only allow approving pending ones.
+func reviewModerationPolicyAttributes(product *database.Product, current *database.Review, status string) map[string]interface{} {
+	attributes := reviewPolicyAttributes(product, current)
+	attributes["status"] = status
+	attributes[currentAttributePrefix+"status"] = current.Status
+	return attributes
+}
+
+func promotionPolicyAttributes(promotion *database.Promotion) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        float64(promotion.ID),
+		"name":      promotion.Name,
+		"kind":      promotion.Kind,
+		"value":     promotion.Value,
+		"priority":  float64(promotion.Priority),
+		"stackable": promotion.Stackable,
+	}
+}
+
+func promotionUpdatePolicyAttributes(current, updated *database.Promotion) map[string]interface{} {
+	return withCurrentAttributes(promotionPolicyAttributes(updated), promotionPolicyAttributes(current))
+}
+
+func categoryPolicyAttributes(category string) map[string]interface{} {
+	return map[string]interface{}{"category": category}
+}
+
+// apiKeyPolicyAttributes leaves out the scopes, conditions only compare single values.
+// On revocation only the ID is known.
+func apiKeyPolicyAttributes(apiKey *database.ApiKey) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   float64(apiKey.ID),
+		"name": apiKey.Name,
+	}
+}
+
+// withCurrentAttributes adds the attributes of the current version of a resource, prefixed with "current_".
+func withCurrentAttributes(attributes, current map[string]interface{}) map[string]interface{} {
+	for name, value := range current {
+		attributes[currentAttributePrefix+name] = value
+	}
+	return attributes
+}
+
+// priceChangePercent returns the absolute change between the prices, in percent of the current one.
+// Any change from a zero price counts as 100%.
+func priceChangePercent(current, updated float64) float64 {
+	if current == updated {
+		return 0
+	}
+	if current == 0 {
+		return 100
+	}
+	return math.Abs(updated-current) / current * 100
+}
@@ -0,0 +1,106 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const editorsPolicy = `{
+	"roles": {
+		"editor": {"permissions": [
+			{"action": "product:update"},
+			{"action": "product:variant:update", "conditions": [
+				{"attribute": "price_change_percent", "operator": "lte", "value": 20},
+				{"attribute": "category", "operator": "eq", "value": "t-shirts"}
+			]}
+		]},
+		"admin": {"permissions": [{"action": "*"}]}
+	},
+	"scope_roles": {"products:write": ["editor"], "products:admin": ["admin"]}
+}`
+
+func sendRequest(t *testing.T, server http.Handler, method, path, scope, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+token(t, scope))
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestUpdateVariant_Unit_Success(t *testing.T) {
+	server, mock := newServer(t, editorsPolicy)
+
+	expectTenantTx(mock)
+	expectProduct(mock, 20, "t-shirts")
+	expectVariant(mock, nil)
+	mock.ExpectExec(updateVariantQuery).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	response := sendRequest(t, server.Handler(), http.MethodPut, fmt.Sprintf("/products/%d/variants/%d", productId, variantId),
+		"products:write", `{"sku": "TS-M", "price": 22, "stock": 10}`)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateVariant_Unit_Fail_ConditionDenied(t *testing.T) {
+	server, mock := newServer(t, editorsPolicy)
+
+	// the variant is sold at the product price, 20: 30 is a 50% increase
+	expectTenantTx(mock)
+	expectProduct(mock, 20, "t-shirts")
+	expectVariant(mock, nil)
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodPut, fmt.Sprintf("/products/%d/variants/%d", productId, variantId),
+		"products:write", `{"sku": "TS-M", "price": 30, "stock": 10}`)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Body.String(), "product:variant:update denied")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteVariant_Unit_Fail_ActionDenied(t *testing.T) {
+	server, mock := newServer(t, editorsPolicy)
+
+	expectTenantTx(mock)
+	expectProduct(mock, 20, "t-shirts")
+	expectVariant(mock, nil)
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodDelete, fmt.Sprintf("/products/%d/variants/%d", productId, variantId),
+		"products:write", "")
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "product:variant:delete denied")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePromotion_Unit_Fail_ActionDenied(t *testing.T) {
+	// products:admin passes the scope check of the route, but the role bound to it only covers products
+	server, mock := newServer(t, `{"roles": {"editor": {"permissions": [{"action": "product:*"}]}}, "scope_roles": {"products:admin": ["editor"]}}`)
+
+	expectTenantTx(mock)
+	mock.ExpectQuery(getPromotionQuery).
+		WithArgs(promotionId).
+		WillReturnRows(sqlmock.NewRows(promotionColumns).
+			AddRow("summer", "percentage", 10, "{}", "{}", nil, nil, 0, false))
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodDelete, fmt.Sprintf("/promotions/%d", promotionId),
+		"products:admin", "")
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "promotion:delete denied")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
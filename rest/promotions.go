@@ -0,0 +1,341 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/pricing"
+)
+
+func (s *Server) getPromotions(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-promotions-handler")
+	defer span.Finish()
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Info("Get promotions")
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get promotions failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	promotions, err := database.GetPromotions(tx, ctx)
+	if err != nil {
+		errMsg := "Get promotions failed: " + err.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("promotions-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotions-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("promotions-found", len(promotions))
+	span.LogKV("promotions-found", len(promotions))
+
+	sendJsonResponse(writer, http.StatusOK, promotions)
+}
+
+func (s *Server) getPromotion(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-promotion-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get promotion failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	promotionId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get promotion by ID: %d", promotionId)
+	span.SetTag("promotion-id", promotionId)
+
+	promotion := &database.Promotion{ID: promotionId}
+	getErr := database.GetPromotion(tx, promotion, ctx)
+	if getErr != nil {
+		var errMsg string
+		switch getErr {
+		case sql.ErrNoRows:
+			errMsg = "Get promotion failed: promotion not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get promotion failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("promotion-found", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-found", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("promotion-found", true)
+	span.LogKV("promotion-found", true)
+
+	sendJsonResponse(writer, http.StatusOK, promotion)
+}
+
+func (s *Server) createPromotion(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "create-promotion-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Create promotion failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	var promotion *database.Promotion
+	unmarshErr := json.NewDecoder(request.Body).Decode(&promotion)
+	if unmarshErr != nil || promotion == nil {
+		errMsg := "Create promotion failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("promotion-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-created", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	validateErr := promotion.Validate()
+	if validateErr != nil {
+		errMsg := "Create promotion failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("promotion-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-created", false, "error", errMsg)
+		return
+	}
+
+	if !s.authorize(writer, request, span, promotionCreateAction, promotionPolicyAttributes(promotion)) {
+		span.SetTag("promotion-created", false)
+		span.LogKV("promotion-created", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create promotion %s", promotion.String())
+
+	createErr := database.CreatePromotion(tx, promotion, ctx)
+	if createErr != nil {
+		errMsg := "Create promotion failed: " + createErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("promotion-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-created", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("promotion", promotion.String())
+	span.SetTag("promotion-created", true)
+	span.LogKV("promotion", promotion.String(), "promotion-created", true)
+
+	sendJsonResponse(writer, http.StatusCreated, promotion)
+}
+
+func (s *Server) updatePromotion(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "update-promotion-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Update promotion failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	promotionId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("promotion-id", promotionId)
+
+	var promotion *database.Promotion
+	unmarshErr := json.NewDecoder(request.Body).Decode(&promotion)
+	if unmarshErr != nil || promotion == nil {
+		errMsg := "Update promotion failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("promotion-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-updated", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	promotion.ID = promotionId
+	validateErr := promotion.Validate()
+	if validateErr != nil {
+		errMsg := "Update promotion failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("promotion-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-updated", false, "error", errMsg)
+		return
+	}
+
+	current := &database.Promotion{ID: promotionId}
+	currentErr := database.GetPromotion(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Update promotion failed: promotion not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Update promotion failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("promotion-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-updated", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, promotionUpdateAction, promotionUpdatePolicyAttributes(current, promotion)) {
+		span.SetTag("promotion-updated", false)
+		span.LogKV("promotion-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Update promotion %s", promotion.String())
+
+	updateErr := database.UpdatePromotion(tx, promotion, ctx)
+	if updateErr != nil {
+		var errMsg string
+		switch updateErr {
+		case sql.ErrNoRows:
+			errMsg = "Update promotion failed: promotion not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Update promotion failed: " + updateErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("promotion-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-updated", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("promotion", promotion.String())
+	span.SetTag("promotion-updated", true)
+	span.LogKV("promotion", promotion.String(), "promotion-updated", true)
+
+	sendJsonResponse(writer, http.StatusOK, promotion)
+}
+
+func (s *Server) deletePromotion(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-promotion-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete promotion failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	promotionId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("promotion-id", promotionId)
+
+	current := &database.Promotion{ID: promotionId}
+	currentErr := database.GetPromotion(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete promotion failed: promotion not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete promotion failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("promotion-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, promotionDeleteAction, promotionPolicyAttributes(current)) {
+		span.SetTag("promotion-deleted", false)
+		span.LogKV("promotion-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete promotion by ID: %d", promotionId)
+
+	deleteErr := database.DeletePromotion(tx, promotionId, ctx)
+	if deleteErr != nil {
+		var errMsg string
+		switch deleteErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete promotion failed: promotion not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete promotion failed: " + deleteErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("promotion-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("promotion-deleted", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("promotion-deleted", true)
+	span.LogKV("promotion-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// priceProducts sets the pricing of the products from the promotions active right now.
+func priceProducts(db database.Querier, products []*database.Product, ctx context.Context) error {
+	if len(products) == 0 {
+		return nil
+	}
+	now := time.Now()
+	promotions, err := database.GetActivePromotions(db, now, ctx)
+	if err != nil {
+		return err
+	}
+	pricing.Apply(products, promotions, now)
+	return nil
+}
@@ -0,0 +1,171 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	rateLimitLimitHeaderKey     = "RateLimit-Limit"
+	rateLimitRemainingHeaderKey = "RateLimit-Remaining"
+	rateLimitResetHeaderKey     = "RateLimit-Reset"
+	retryAfterHeaderKey         = "Retry-After"
+	forwardedForHeaderKey       = "X-Forwarded-For"
+
+	rateLimitAllowed = "allowed"
+	rateLimitLimited = "limited"
+
+	// apiKeyLookupRoute stands for the API key lookups in buckets and metrics, they happen before routes are matched
+	apiKeyLookupRoute = "api-key-lookup"
+)
+
+// pathVariableRegexp matches the pattern of path variables, so that "/products/{id:[0-9]+}" is configured as
+// "/products/{id}"
+var pathVariableRegexp = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// rateLimitMiddleware limits the requests of each client to each route, see ratelimit.Rules.
+// It runs after authentication so that clients are told apart by principal rather than by IP when possible, the API
+// key lookups of authentication being limited beforehand by allowApiKeyLookup.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		template := routeTemplate(request)
+		limit := s.rateLimits.For(request.Method, template)
+		if limit == nil {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		route := request.Method + " " + template
+		client := s.clientKey(request)
+		result := s.limiter.Allow(route+"|"+client, limit, time.Now())
+		writer.Header().Set(rateLimitLimitHeaderKey, strconv.Itoa(result.Limit))
+		writer.Header().Set(rateLimitRemainingHeaderKey, strconv.Itoa(result.Remaining))
+		writer.Header().Set(rateLimitResetHeaderKey, ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			IncreaseRateLimitDecisions(route, rateLimitLimited)
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Infof("Rate limit of %s exceeded by %s", route, client)
+			writer.Header().Set(retryAfterHeaderKey, ceilSeconds(result.RetryAfter))
+			sendProblemResponse(writer, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		IncreaseRateLimitDecisions(route, rateLimitAllowed)
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// routeTemplate returns the path template of the matched route without variable patterns, e.g. "/products/{id}".
+func routeTemplate(request *http.Request) string {
+	if route := mux.CurrentRoute(request); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return pathVariableRegexp.ReplaceAllString(template, "{$1}")
+		}
+	}
+	return request.URL.Path
+}
+
+// allowApiKeyLookup limits by IP address the API key lookups of apiKeyAuthenticationMiddleware. They query the
+// database before rateLimitMiddleware can tell the caller apart, so bogus keys would otherwise never be limited.
+// Refused lookups are answered with 429.
+func (s *Server) allowApiKeyLookup(writer http.ResponseWriter, request *http.Request) bool {
+	limit := s.rateLimits.ForApiKeys()
+	if limit == nil {
+		return true
+	}
+
+	client := "ip:" + s.clientIP(request)
+	result := s.limiter.Allow(apiKeyLookupRoute+"|"+client, limit, time.Now())
+	if !result.Allowed {
+		IncreaseRateLimitDecisions(apiKeyLookupRoute, rateLimitLimited)
+		logging.NamedFromContext(logging.RestLogger, request.Context()).Infof("Rate limit of %s exceeded by %s", apiKeyLookupRoute, client)
+		writer.Header().Set(retryAfterHeaderKey, ceilSeconds(result.RetryAfter))
+		sendProblemResponse(writer, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+	IncreaseRateLimitDecisions(apiKeyLookupRoute, rateLimitAllowed)
+	return true
+}
+
+// clientKey identifies the caller: by tenant and subject if authenticated, by IP address otherwise.
+func (s *Server) clientKey(request *http.Request) string {
+	if principal := principalFromRequest(request); principal != nil {
+		return "sub:" + principal.Tenant + "/" + principal.Subject
+	}
+	return "ip:" + s.clientIP(request)
+}
+
+// clientIP returns the remote address of the request, or if it comes from a trusted proxy the last address of
+// X-Forwarded-For that is not a trusted proxy: addresses on the left of it may have been forged by the client.
+func (s *Server) clientIP(request *http.Request) string {
+	remote, _, splitErr := net.SplitHostPort(request.RemoteAddr)
+	if splitErr != nil {
+		remote = request.RemoteAddr
+	}
+	if !s.isTrustedProxy(remote) {
+		return remote
+	}
+
+	forwarded := strings.Split(strings.Join(request.Header.Values(forwardedForHeaderKey), ","), ",")
+	for index := len(forwarded) - 1; index >= 0; index-- {
+		address := strings.TrimSpace(forwarded[index])
+		if address == "" {
+			continue
+		}
+		if !s.isTrustedProxy(address) {
+			return address
+		}
+		remote = address
+	}
+	return remote
+}
+
+func (s *Server) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses a comma separated list of IPs and CIDRs.
+func parseTrustedProxies(proxies string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0)
+	for _, proxy := range strings.Split(proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q not valid: %s", proxy, err.Error())
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func ceilSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
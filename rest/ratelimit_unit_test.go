@@ -0,0 +1,39 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyLookup_Unit_Limited(t *testing.T) {
+	require.NoError(t, os.Setenv(apiKeyLimitKey, "1/m"))
+	defer os.Unsetenv(apiKeyLimitKey)
+	server, mock := newServer(t, "")
+
+	// only the first bogus key reaches the database
+	mock.ExpectQuery(getApiKeyQuery).
+		WithArgs("bogus").
+		WillReturnError(sql.ErrNoRows)
+
+	send := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/products", nil)
+		request.Header.Set("X-API-Key", "bogus.secret")
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send().Code)
+	limited := send()
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.NotEmpty(t, limited.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
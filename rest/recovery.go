@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"go.uber.org/zap"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+// recoveryMiddleware answers 500 with a problem response when serving the request panics, instead of letting
+// net/http drop the connection. The panic is logged with its stack, counted by route and recorded in the span of the
+// request, see tracingMiddleware.
+func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// deliberate abort of the response, net/http closes the connection without logging
+				panic(recovered)
+			}
+
+			// deferred functions run before the stack unwinds, so it still shows where the panic happened
+			stack := debug.Stack()
+			if span := opentracing.SpanFromContext(request.Context()); span != nil {
+				ext.Error.Set(span, true)
+				span.LogKV("event", "error", "error.kind", "panic", "message", fmt.Sprint(recovered), "stack", string(stack))
+			}
+
+			route := request.Method + " " + s.matchedRouteTemplate(request)
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Desugar().Error("Request panicked",
+				zap.String("route", route),
+				zap.String("panic", fmt.Sprint(recovered)),
+				zap.String("trace_id", accessLogTraceId(request)),
+				zap.ByteString("stack", stack),
+			)
+			IncreasePanics(route)
+
+			sendProblemResponse(writer, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(writer, request)
+	})
+}
@@ -0,0 +1,49 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/rest"
+)
+
+func TestRecovery_Unit_Panic(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	server, mock := newServer(t, "")
+	server.HandleFunc("/panic", func(writer http.ResponseWriter, request *http.Request) {
+		panic("boom")
+	})
+	panics := rest.Panics.WithLabelValues("GET /panic")
+	before := testutil.ToFloat64(panics)
+
+	expectTenantTx(mock)
+	mock.ExpectRollback()
+
+	response := sendRequest(t, server.Handler(), http.MethodGet, "/panic", "products:read", "")
+
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Body.String(), "internal server error")
+	assert.Equal(t, before+1, testutil.ToFloat64(panics))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	var requestSpan *mocktracer.MockSpan
+	for _, span := range tracer.FinishedSpans() {
+		if span.OperationName == "http-request" {
+			requestSpan = span
+		}
+	}
+	require.NotNil(t, requestSpan)
+	assert.Equal(t, true, requestSpan.Tag("error"))
+}
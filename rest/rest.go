@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/bygui86/go-postgres-cicd/auth"
 	"github.com/bygui86/go-postgres-cicd/database"
 	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/policy"
+	"github.com/bygui86/go-postgres-cicd/ratelimit"
+	"github.com/bygui86/go-postgres-cicd/storage"
 )
 
 func New(enableTracing bool) (*Server, error) {
-	logging.Log.Info("Create new REST server")
+	logging.Named(logging.RestLogger).Info("Create new REST server")
 
 	cfg := loadConfig()
 
@@ -28,8 +32,7 @@ func New(enableTracing bool) (*Server, error) {
 
 	pingErr := database.PingDb(db, 10)
 	if pingErr != nil {
-		logging.SugaredLog.Errorf("PostgreSQL connection failed: %s", pingErr.Error())
-		panic(pingErr)
+		return nil, fmt.Errorf("PostgreSQL connection failed: %s", pingErr.Error())
 	}
 
 	initErr := database.InitDb(db)
@@ -37,9 +40,59 @@ func New(enableTracing bool) (*Server, error) {
 		return nil, initErr
 	}
 
+	blobStore, blobStoreErr := storage.New()
+	if blobStoreErr != nil {
+		return nil, blobStoreErr
+	}
+
+	verifier, verifierErr := auth.NewVerifier()
+	if verifierErr != nil {
+		return nil, verifierErr
+	}
+
+	policies, policiesErr := policy.New()
+	if policiesErr != nil {
+		return nil, policiesErr
+	}
+
+	limiter, limiterErr := ratelimit.New()
+	if limiterErr != nil {
+		return nil, limiterErr
+	}
+	rateLimits, rateLimitsErr := ratelimit.LoadRules()
+	if rateLimitsErr != nil {
+		return nil, rateLimitsErr
+	}
+	trustedProxies, trustedProxiesErr := parseTrustedProxies(cfg.trustedProxies)
+	if trustedProxiesErr != nil {
+		return nil, trustedProxiesErr
+	}
+
+	priceBounds, priceBoundsErr := parsePriceBounds(cfg.priceBands)
+	if priceBoundsErr != nil {
+		return nil, priceBoundsErr
+	}
+
+	routeTimeouts, routeTimeoutsErr := parseRouteTimeouts(cfg.routeTimeouts)
+	if routeTimeoutsErr != nil {
+		return nil, routeTimeoutsErr
+	}
+	baseCtx, cancelBaseCtx := context.WithCancel(context.Background())
+
 	server := &Server{
-		config: cfg,
-		db:     db,
+		config:         cfg,
+		db:             db,
+		scheduler:      newScheduler(db, cfg.schedulerInterval),
+		catalogue:      newCatalogueCollector(db, cfg.catalogueInterval, cfg.catalogueTimeout, priceBounds),
+		blobStore:      blobStore,
+		verifier:       verifier,
+		policies:       policies,
+		limiter:        limiter,
+		rateLimits:     rateLimits,
+		trustedProxies: trustedProxies,
+		routeTimeouts:  routeTimeouts,
+		baseCtx:        baseCtx,
+		cancelBaseCtx:  cancelBaseCtx,
 	}
 
 	server.setupRouter()
@@ -48,21 +101,24 @@ func New(enableTracing bool) (*Server, error) {
 }
 
 func (s *Server) Start() error {
-	logging.Log.Info("Start REST server")
+	logging.Named(logging.RestLogger).Info("Start REST server")
 
 	if s.httpServer != nil && !s.running {
 		var err error
 		go func() {
 			err = s.httpServer.ListenAndServe()
 			if err != nil {
-				logging.SugaredLog.Errorf("REST server start failed: %s", err.Error())
+				logging.Named(logging.RestLogger).Errorf("REST server start failed: %s", err.Error())
 			}
 		}()
 		if err != nil {
 			return err
 		}
 		s.running = true
-		logging.SugaredLog.Infof("REST server listening on port %d", s.config.restPort)
+		logging.Named(logging.RestLogger).Infof("REST server listening on port %d", s.config.restPort)
+
+		s.scheduler.start()
+		s.catalogue.start()
 		return nil
 	}
 
@@ -70,7 +126,7 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Shutdown(timeout int) {
-	logging.SugaredLog.Warnf("Shutdown REST server, timeout %d", timeout)
+	logging.Named(logging.RestLogger).Warnf("Shutdown REST server, timeout %d", timeout)
 
 	if s.httpServer != nil && s.running {
 		// create a deadline to wait for.
@@ -79,8 +135,13 @@ func (s *Server) Shutdown(timeout int) {
 		// does not block if no connections, otherwise wait until the timeout deadline
 		err := s.httpServer.Shutdown(ctx)
 		if err != nil {
-			logging.SugaredLog.Errorf("Error shutting down REST server: %s", err.Error())
+			logging.Named(logging.RestLogger).Errorf("Error shutting down REST server: %s", err.Error())
 		}
+		// requests still running past the timeout are aborted, their queries with them
+		s.cancelBaseCtx()
+
+		s.scheduler.shutdown()
+		s.catalogue.shutdown()
 
 		s.db.Close()
 
@@ -88,5 +149,5 @@ func (s *Server) Shutdown(timeout int) {
 		return
 	}
 
-	logging.Log.Error("REST server shutdown failed: HTTP server not initialized or HTTP server not running")
+	logging.Named(logging.RestLogger).Error("REST server shutdown failed: HTTP server not initialized or HTTP server not running")
 }
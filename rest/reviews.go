@@ -0,0 +1,419 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func (s *Server) getReviews(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-reviews-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get reviews failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get reviews of product %d", productId)
+	span.SetTag("product-id", productId)
+
+	count, _ := strconv.Atoi(request.FormValue("count"))
+	start, _ := strconv.Atoi(request.FormValue("start"))
+	if count > 10 || count < 1 {
+		count = 10
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	statuses, statusCode, statusErr := reviewStatusesFromRequest(request)
+	if statusErr != nil {
+		errMsg := "Get reviews failed: " + statusErr.Error()
+		sendErrorResponse(writer, statusCode, errMsg)
+
+		span.SetTag("reviews-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("reviews-found", 0, "error", errMsg)
+		return
+	}
+	span.SetTag("statuses", statuses)
+
+	getErr := checkProductVisible(tx, request, productId, ctx)
+	var reviews []*database.Review
+	if getErr == nil {
+		reviews, getErr = database.GetReviews(tx, productId, start, count, statuses, ctx)
+	}
+	if getErr != nil {
+		var errMsg string
+		switch getErr {
+		case sql.ErrNoRows:
+			errMsg = "Get reviews failed: product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get reviews failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("reviews-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("reviews-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("reviews-found", len(reviews))
+	span.LogKV("reviews-found", len(reviews))
+
+	sendJsonResponse(writer, http.StatusOK, reviews)
+}
+
+func (s *Server) createReview(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "create-review-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Create review failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("product-id", productId)
+
+	var review *database.Review
+	unmarshErr := json.NewDecoder(request.Body).Decode(&review)
+	if unmarshErr != nil || review == nil {
+		errMsg := "Create review failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("review-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-created", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	review.ProductID = productId
+	// authenticated reviewers always sign with their own subject
+	if principal := principalFromRequest(request); principal != nil {
+		review.Author = principal.Subject
+	}
+	validateErr := review.Validate()
+	if validateErr != nil {
+		errMsg := "Create review failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("review-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-created", false, "error", errMsg)
+		return
+	}
+
+	// only visible products can be reviewed
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr == nil && !isProductVisible(request, product) {
+		productCode, productErr = http.StatusNotFound, errors.New("product not found")
+	}
+	if productErr != nil {
+		errMsg := "Create review failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("review-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-created", false, "error", errMsg)
+		return
+	}
+	// the status of the review is always pending until moderated, whatever the payload says
+	review.Status = database.ReviewStatusPending
+	if !s.authorize(writer, request, span, reviewCreateAction, reviewPolicyAttributes(product, review)) {
+		span.SetTag("review-created", false)
+		span.LogKV("review-created", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create review %s", review.String())
+
+	createErr := database.CreateReview(tx, review, ctx)
+	if createErr != nil {
+		errMsg := "Create review failed: "
+		switch {
+		case database.IsForeignKeyViolation(createErr):
+			errMsg += "product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg += createErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("review-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-created", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("review", review.String())
+	span.SetTag("review-created", true)
+	span.LogKV("review", review.String(), "review-created", true)
+
+	afterCommit(request, IncreaseReviewsSubmitted)
+
+	sendJsonResponse(writer, http.StatusCreated, review)
+}
+
+func (s *Server) moderateReview(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "moderate-review-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Moderate review failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, reviewId := reviewIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("review-id", reviewId)
+
+	if !hasScope(request, productsAdminScope) {
+		errMsg := "Moderate review failed: " + productsAdminScope + " scope required"
+		sendErrorResponse(writer, http.StatusForbidden, errMsg)
+
+		span.SetTag("review-moderated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-moderated", false, "error", errMsg)
+		return
+	}
+
+	var review *database.Review
+	unmarshErr := json.NewDecoder(request.Body).Decode(&review)
+	if unmarshErr != nil || review == nil || !database.IsValidReviewStatus(review.Status) {
+		errMsg := "Moderate review failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("review-moderated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-moderated", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	status := review.Status
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Moderate review failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("review-moderated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-moderated", false, "error", errMsg)
+		return
+	}
+	current := &database.Review{ID: reviewId, ProductID: productId}
+	currentErr := database.GetReview(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Moderate review failed: review not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Moderate review failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("review-moderated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-moderated", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, reviewModerateAction, reviewModerationPolicyAttributes(product, current, status)) {
+		span.SetTag("review-moderated", false)
+		span.LogKV("review-moderated", false)
+		return
+	}
+
+	review = &database.Review{ID: reviewId, ProductID: productId, Status: status}
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Moderate review %s", review.String())
+
+	moderateErr := database.ModerateReview(tx, review, ctx)
+	if moderateErr == nil {
+		moderateErr = database.GetReview(tx, review, ctx)
+	}
+	if moderateErr != nil {
+		var errMsg string
+		switch moderateErr {
+		case sql.ErrNoRows:
+			errMsg = "Moderate review failed: review not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Moderate review failed: " + moderateErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("review-moderated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-moderated", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("review", review.String())
+	span.SetTag("review-moderated", true)
+	span.LogKV("review", review.String(), "review-moderated", true)
+
+	afterCommit(request, func() { IncreaseReviewsModerated(status) })
+
+	sendJsonResponse(writer, http.StatusOK, review)
+}
+
+func (s *Server) deleteReview(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-review-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete review failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, reviewId := reviewIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("review-id", reviewId)
+
+	if !hasScope(request, productsAdminScope) {
+		errMsg := "Delete review failed: " + productsAdminScope + " scope required"
+		sendErrorResponse(writer, http.StatusForbidden, errMsg)
+
+		span.SetTag("review-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-deleted", false, "error", errMsg)
+		return
+	}
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Delete review failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("review-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-deleted", false, "error", errMsg)
+		return
+	}
+	current := &database.Review{ID: reviewId, ProductID: productId}
+	currentErr := database.GetReview(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete review failed: review not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete review failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("review-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, reviewDeleteAction, reviewPolicyAttributes(product, current)) {
+		span.SetTag("review-deleted", false)
+		span.LogKV("review-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete review %d of product %d", reviewId, productId)
+
+	deleteErr := database.DeleteReview(tx, productId, reviewId, ctx)
+	if deleteErr != nil {
+		var errMsg string
+		switch deleteErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete review failed: review not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete review failed: " + deleteErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("review-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("review-deleted", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("review-deleted", true)
+	span.LogKV("review-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// reviewStatusesFromRequest returns the review statuses to list: everybody sees approved reviews,
+// only the admin scope can ask for other statuses through the status parameter.
+func reviewStatusesFromRequest(request *http.Request) ([]string, int, error) {
+	statusParam := request.FormValue(statusQueryParam)
+	if statusParam == "" {
+		return []string{database.ReviewStatusApproved}, http.StatusOK, nil
+	}
+	if !hasScope(request, productsAdminScope) {
+		return nil, http.StatusForbidden, fmt.Errorf("status filter requires %s scope", productsAdminScope)
+	}
+	if statusParam == statusQueryAll {
+		return []string{database.ReviewStatusPending, database.ReviewStatusApproved, database.ReviewStatusRejected},
+			http.StatusOK, nil
+	}
+
+	statuses := strings.Split(statusParam, ",")
+	for _, status := range statuses {
+		if !database.IsValidReviewStatus(status) {
+			return nil, http.StatusBadRequest, fmt.Errorf("status %q not valid", status)
+		}
+	}
+	return statuses, http.StatusOK, nil
+}
+
+// reviewIdsFromRequest returns the product and review IDs, already validated as numbers by the route patterns.
+func reviewIdsFromRequest(request *http.Request) (int, int) {
+	vars := mux.Vars(request)
+	productId, _ := strconv.Atoi(vars["id"])
+	reviewId, _ := strconv.Atoi(vars["reviewId"])
+	return productId, reviewId
+}
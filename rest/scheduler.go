@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func newScheduler(db *sql.DB, interval time.Duration) *scheduler {
+	return &scheduler{
+		db:       db,
+		interval: interval,
+	}
+}
+
+func (s *scheduler) start() {
+	logging.Named(logging.RestLogger).Infof("Start product lifecycle scheduler, interval %s", s.interval)
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		// catch up with transitions missed while the service was down
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// shutdown stops the scheduler and waits for a running cycle to complete, so the DB can be safely closed afterwards.
+func (s *scheduler) shutdown() {
+	logging.Named(logging.RestLogger).Warn("Shutdown product lifecycle scheduler")
+
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func (s *scheduler) runOnce() {
+	span := opentracing.StartSpan("product-lifecycle-scheduler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	ctx, cancel := context.WithTimeout(opentracing.ContextWithSpan(context.Background(), span), s.interval)
+	defer cancel()
+
+	// the scheduler connects as table owner, which row level security does not restrict, so it covers all tenants
+	published, publishErr := database.PublishScheduledProducts(s.db, ctx)
+	if publishErr != nil {
+		logging.Named(logging.RestLogger).Errorf("Publish scheduled products failed: %s", publishErr.Error())
+		span.SetTag("error", publishErr.Error())
+		span.LogKV("error", publishErr.Error())
+		return
+	}
+
+	archived, archiveErr := database.ArchiveExpiredProducts(s.db, ctx)
+	if archiveErr != nil {
+		logging.Named(logging.RestLogger).Errorf("Archive expired products failed: %s", archiveErr.Error())
+		span.SetTag("error", archiveErr.Error())
+		span.LogKV("error", archiveErr.Error())
+		return
+	}
+
+	if published > 0 || archived > 0 {
+		logging.Named(logging.RestLogger).Infof("Product lifecycle updated: %d published, %d archived", published, archived)
+	}
+	span.SetTag("products-published", published)
+	span.SetTag("products-archived", archived)
+	span.LogKV("products-published", published, "products-archived", archived)
+}
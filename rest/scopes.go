@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/auth"
+)
+
+const (
+	productsReadScope  = "products:read"
+	productsWriteScope = "products:write"
+	productsAdminScope = "products:admin"
+)
+
+// impliedScopes lists for each scope the scopes it includes: admin includes write, write includes read.
+var impliedScopes = map[string][]string{
+	productsAdminScope: {productsAdminScope, productsWriteScope, productsReadScope},
+	productsWriteScope: {productsWriteScope, productsReadScope},
+}
+
+// validateScopes checks that only scopes known to this service are granted.
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope != productsReadScope && scope != productsWriteScope && scope != productsAdminScope {
+			return fmt.Errorf("scope %q not valid", scope)
+		}
+	}
+	return nil
+}
+
+type principalContextKey struct{}
+
+// principalFromRequest returns the principal authenticated by authenticationMiddleware, nil for anonymous requests.
+func principalFromRequest(request *http.Request) *auth.Principal {
+	principal, _ := request.Context().Value(principalContextKey{}).(*auth.Principal)
+	return principal
+}
+
+// hasScope reports whether the request was granted the given scope.
+// Anonymous requests have no scopes and only get access to public data.
+func hasScope(request *http.Request, scope string) bool {
+	principal := principalFromRequest(request)
+	if principal == nil {
+		return false
+	}
+	for _, granted := range principal.Scopes {
+		implied, found := impliedScopes[granted]
+		if !found {
+			implied = []string{granted}
+		}
+		for _, impliedScope := range implied {
+			if impliedScope == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopedHandler is the handler of a route served only to requests granted its scope, see scopeMiddleware.
+type scopedHandler struct {
+	scope   string
+	handler http.HandlerFunc
+}
+
+func (h *scopedHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	h.handler(writer, request)
+}
+
+// requireScope marks the handler of a route as served only to requests granted the given scope.
+func requireScope(scope string, handler http.HandlerFunc) http.Handler {
+	return &scopedHandler{scope: scope, handler: handler}
+}
+
+// scopeMiddleware enforces the scope of the matched route, see requireScope, before tenancyMiddleware opens the
+// transaction of the request, so that rejected requests never hold a connection of the pool.
+// Anonymous GET requests are let through on products:read if anonymous reads are enabled.
+func (s *Server) scopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		route := mux.CurrentRoute(request)
+		if route == nil {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		scoped, ok := route.GetHandler().(*scopedHandler)
+		if !ok {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if principalFromRequest(request) == nil {
+			if scoped.scope == productsReadScope && request.Method == http.MethodGet && s.config.anonymousReads {
+				next.ServeHTTP(writer, request)
+				return
+			}
+			writer.Header().Set(wwwAuthenticateHeaderKey, "Bearer")
+			sendProblemResponse(writer, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		if !hasScope(request, scoped.scope) {
+			writer.Header().Set(wwwAuthenticateHeaderKey, `Bearer error="insufficient_scope", scope="`+scoped.scope+`"`)
+			sendProblemResponse(writer, http.StatusForbidden, scoped.scope+" scope required")
+			return
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
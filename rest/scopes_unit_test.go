@@ -0,0 +1,37 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes_Unit_Fail_Anonymous(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	// no transaction is expected: the request is rejected before it is opened
+	request := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/products/%d", productId), strings.NewReader(`{}`))
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, "Bearer", response.Header().Get("WWW-Authenticate"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScopes_Unit_Fail_InsufficientScope(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	response := sendRequest(t, server.Handler(), http.MethodDelete, fmt.Sprintf("/promotions/%d", promotionId),
+		"products:write", "")
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "products:admin scope required")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,202 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const tenantHeaderKey = "X-Tenant-ID"
+
+var (
+	errTenantMismatch  = errors.New("tenant not granted by token")
+	errTenantUnbound   = errors.New("token not bound to a tenant")
+	errTenantTxMissing = errors.New("transaction of the tenant missing")
+	errTenantMissing   = errors.New("tenant of the request missing")
+)
+
+// streamingRoutes transfer image content, which can take long. Instead of holding a transaction, and a connection
+// of the pool, for the whole request and buffering the response, their handlers stream the content directly and run
+// their queries in short transactions, see runInTenantTx.
+var streamingRoutes = map[string]bool{
+	http.MethodGet + " " + pathVariableRegexp.ReplaceAllString(productImageContentEndpoint, "{$1}"): true,
+	http.MethodPost + " " + pathVariableRegexp.ReplaceAllString(productImagesEndpoint, "{$1}"):      true,
+}
+
+type tenantContextKey struct{}
+
+type tenantTxContextKey struct{}
+
+type commitHooksContextKey struct{}
+
+// tenancyMiddleware runs every request in a transaction scoped to its tenant, see database.BeginTenantTx.
+// The transaction is committed only if the handler answers with a success status, otherwise it is rolled back.
+// Requests whose context ends while being served, or whose transaction is cancelled by PostgreSQL, are answered by
+// sendCancelledResponse instead of their handler.
+// Streaming routes only get their tenant, see streamingRoutes.
+func (s *Server) tenancyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tenantId, tenantErr := s.tenantFromRequest(request)
+		if tenantErr == errTenantMismatch || tenantErr == errTenantUnbound {
+			sendProblemResponse(writer, http.StatusForbidden, tenantErr.Error())
+			return
+		}
+		if tenantErr != nil {
+			sendErrorResponse(writer, http.StatusBadRequest, tenantErr.Error())
+			return
+		}
+
+		if streamingRoutes[request.Method+" "+routeTemplate(request)] {
+			ctx := context.WithValue(request.Context(), tenantContextKey{}, tenantId)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+			return
+		}
+
+		tx, txErr := database.BeginTenantTx(s.db, tenantId, request.Context())
+		if txErr != nil && (request.Context().Err() != nil || database.IsCancelled(txErr)) {
+			s.sendCancelledResponse(writer, request)
+			return
+		}
+		if txErr != nil {
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Errorf("Begin transaction of tenant %s failed: %s", tenantId, txErr.Error())
+			sendErrorResponse(writer, http.StatusInternalServerError, "begin transaction failed")
+			return
+		}
+		defer tx.Rollback()
+
+		hooks := &commitHooks{}
+		ctx := context.WithValue(request.Context(), tenantContextKey{}, tenantId)
+		ctx = context.WithValue(ctx, tenantTxContextKey{}, tx)
+		ctx = context.WithValue(ctx, commitHooksContextKey{}, hooks)
+
+		// the buffer starts with the headers already set, e.g. X-Request-ID, but changes reach the client only on flush
+		buffered := &bufferedResponseWriter{header: writer.Header().Clone(), status: http.StatusOK}
+		next.ServeHTTP(buffered, request.WithContext(ctx))
+
+		// whatever the handler answered, its queries were cut short and the transaction is already rolled back
+		if request.Context().Err() != nil {
+			s.sendCancelledResponse(writer, request)
+			return
+		}
+
+		if buffered.status < http.StatusBadRequest {
+			commitErr := tx.Commit()
+			if commitErr != nil && database.IsCancelled(commitErr) {
+				s.sendCancelledResponse(writer, request)
+				return
+			}
+			if commitErr != nil {
+				logging.NamedFromContext(logging.RestLogger, request.Context()).Errorf("Commit transaction of tenant %s failed: %s", tenantId, commitErr.Error())
+				sendErrorResponse(writer, http.StatusInternalServerError, "commit transaction failed")
+				return
+			}
+			for _, hook := range hooks.hooks {
+				hook()
+			}
+		}
+		buffered.flush(writer)
+	})
+}
+
+// afterCommit registers a side effect of the handler to run only once the transaction of the request is committed,
+// e.g. deleting image content whose metadata would survive a rollback.
+func afterCommit(request *http.Request, hook func()) {
+	if hooks, found := request.Context().Value(commitHooksContextKey{}).(*commitHooks); found {
+		hooks.hooks = append(hooks.hooks, hook)
+	}
+}
+
+// runInTenantTx runs the queries of streaming routes in a transaction of the request tenant, committed if they
+// succeed. Their errors are returned as they are, e.g. sql.ErrNoRows.
+func (s *Server) runInTenantTx(request *http.Request, ctx context.Context, queries func(tx database.Querier) error) error {
+	tenantId, found := request.Context().Value(tenantContextKey{}).(string)
+	if !found {
+		return errTenantMissing
+	}
+
+	tx, txErr := database.BeginTenantTx(s.db, tenantId, ctx)
+	if txErr != nil {
+		return txErr
+	}
+	defer tx.Rollback()
+
+	queriesErr := queries(tx)
+	if queriesErr != nil {
+		return queriesErr
+	}
+	return tx.Commit()
+}
+
+// tenantFromRequest returns the tenant of the principal, from the tenant_id claim of its token or the row of its
+// API key. Principals not bound to a tenant are rejected, as is a header naming another tenant than theirs.
+// Anonymous requests belong to the tenant named by the X-Tenant-ID header, or the default tenant if configured.
+func (s *Server) tenantFromRequest(request *http.Request) (string, error) {
+	tenantId := request.Header.Get(tenantHeaderKey)
+	if principal := principalFromRequest(request); principal != nil {
+		if principal.Tenant == "" {
+			return "", errTenantUnbound
+		}
+		if tenantId != "" && tenantId != principal.Tenant {
+			return "", errTenantMismatch
+		}
+		tenantId = principal.Tenant
+	}
+	if tenantId == "" {
+		tenantId = s.config.defaultTenant
+	}
+	if tenantId == "" {
+		return "", fmt.Errorf("%s header missing", tenantHeaderKey)
+	}
+	return tenantId, database.ValidateTenantId(tenantId)
+}
+
+// tenantTx returns the transaction of the request tenant, set up by tenancyMiddleware, or an error for a handler
+// reached without it.
+func tenantTx(request *http.Request) (database.Querier, error) {
+	tx, found := request.Context().Value(tenantTxContextKey{}).(*sql.Tx)
+	if !found {
+		return nil, errTenantTxMissing
+	}
+	return tx, nil
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(content []byte) (int, error) {
+	return w.body.Write(content)
+}
+
+func (w *bufferedResponseWriter) flush(writer http.ResponseWriter) {
+	for key, values := range w.header {
+		writer.Header()[key] = values
+	}
+	writer.WriteHeader(w.status)
+	_, err := w.body.WriteTo(writer)
+	if err != nil {
+		logging.Named(logging.RestLogger).Errorf("Error sending buffered response: %s", err.Error())
+	}
+}
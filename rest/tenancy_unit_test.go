@@ -0,0 +1,51 @@
+// +build !integration
+
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenancy_Unit_Fail_TokenWithoutTenant(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	claims := jwt.MapClaims{
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "products:admin",
+	}
+	signed, signErr := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(hs256Secret))
+	require.NoError(t, signErr)
+
+	request := httptest.NewRequest(http.MethodGet, "/apikeys", nil)
+	request.Header.Set("Authorization", "Bearer "+signed)
+	request.Header.Set("X-Tenant-ID", "other")
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Equal(t, "application/problem+json", response.Header().Get("Content-Type"))
+	assert.Contains(t, response.Body.String(), "token not bound to a tenant")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenancy_Unit_Fail_HeaderMismatch(t *testing.T) {
+	server, mock := newServer(t, "")
+
+	request := httptest.NewRequest(http.MethodGet, "/apikeys", nil)
+	request.Header.Set("Authorization", "Bearer "+token(t, "products:admin"))
+	request.Header.Set("X-Tenant-ID", "other")
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "tenant not granted by token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
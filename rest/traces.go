@@ -7,21 +7,46 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 
+	"github.com/bygui86/go-postgres-cicd/commons"
 	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/tracing"
 )
 
+const requestOperationName = "http-request"
+
+// tracingMiddleware starts the span of the request, referring to the span of the RPC client if available, and stores
+// it in the context: handlers trace their work in child spans, and recoveryMiddleware marks it if serving panics.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		clientSpanContext, clientSpanErr := opentracing.GlobalTracer().Extract(
+			opentracing.HTTPHeaders,
+			opentracing.HTTPHeadersCarrier(request.Header))
+		if clientSpanErr != nil {
+			logging.NamedFromContext(logging.RestLogger, request.Context()).Debugf("%s", clientSpanErr.Error())
+		}
+
+		// If clientSpanContext == nil, a root span will be created.
+		span := opentracing.StartSpan(requestOperationName, ext.RPCServerOption(clientSpanContext))
+		defer span.Finish()
+
+		span.SetTag("app", commons.ServiceName)
+		span.SetTag("request-id", logging.RequestId(request.Context()))
+		ext.HTTPMethod.Set(span, request.Method)
+		ext.HTTPUrl.Set(span, request.URL.Path)
+		setAccessLogTraceId(request, tracing.TraceID(span.Context()), tracing.SampledTraceID(span.Context()))
+
+		next.ServeHTTP(writer, request.WithContext(opentracing.ContextWithSpan(request.Context(), span)))
+	})
+}
+
+// retrieveSpanAndCtx starts the span of the handler, child of the span of the request.
 func retrieveSpanAndCtx(request *http.Request, operationName string) (opentracing.Span, context.Context) {
-	clientSpanContext, clientSpanErr := opentracing.GlobalTracer().Extract(
-		opentracing.HTTPHeaders,
-		opentracing.HTTPHeadersCarrier(request.Header))
-	if clientSpanErr != nil {
-		logging.SugaredLog.Debugf("%s", clientSpanErr.Error())
+	// queries run with the request context, so that they stop when the client goes away or the deadline is reached
+	span, ctx := opentracing.StartSpanFromContext(request.Context(), operationName)
+	if principal := principalFromRequest(request); principal != nil {
+		span.SetTag("subject", principal.Subject)
 	}
-
-	// Create the span referring to the RPC client if available.
-	// If clientSpanContext == nil, a root span will be created.
-	span := opentracing.StartSpan(operationName, ext.RPCServerOption(clientSpanContext))
-	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	span.SetTag("request-id", logging.RequestId(request.Context()))
 
 	return span, ctx
 }
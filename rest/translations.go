@@ -0,0 +1,204 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func (s *Server) getTranslations(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-translations-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get translations failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get translations of product %d", productId)
+	span.SetTag("product-id", productId)
+
+	translations, err := database.GetTranslations(tx, productId, ctx)
+	if err != nil {
+		errMsg := "Get translations failed: " + err.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("translations-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("translations-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("translations-found", len(translations))
+	span.LogKV("translations-found", len(translations))
+
+	sendJsonResponse(writer, http.StatusOK, translations)
+}
+
+func (s *Server) putTranslation(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "put-translation-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Put translation failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	vars := mux.Vars(request)
+	productId, _ := strconv.Atoi(vars["id"])
+	span.SetTag("product-id", productId)
+
+	locale, localeErr := database.NormalizeLocale(vars["locale"])
+	if localeErr != nil {
+		errMsg := "Put translation failed: " + localeErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("translation-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-updated", false, "error", errMsg)
+		return
+	}
+	span.SetTag("locale", locale)
+
+	var translation *database.Translation
+	unmarshErr := json.NewDecoder(request.Body).Decode(&translation)
+	if unmarshErr != nil || translation == nil || translation.Name == "" {
+		errMsg := "Put translation failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("translation-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-updated", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	translation.Locale = locale
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Put translation failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("translation-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-updated", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, translationUpdateAction, translationPolicyAttributes(product, locale)) {
+		span.SetTag("translation-updated", false)
+		span.LogKV("translation-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Put %s translation of product %d", locale, productId)
+
+	upsertErr := database.UpsertTranslation(tx, productId, translation, ctx)
+	if upsertErr != nil {
+		errMsg := "Put translation failed: "
+		switch {
+		case database.IsForeignKeyViolation(upsertErr):
+			errMsg += "product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg += upsertErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("translation-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-updated", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("translation-updated", true)
+	span.LogKV("translation-updated", true)
+
+	sendJsonResponse(writer, http.StatusOK, translation)
+}
+
+func (s *Server) deleteTranslation(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-translation-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete translation failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	vars := mux.Vars(request)
+	productId, _ := strconv.Atoi(vars["id"])
+	locale, _ := database.NormalizeLocale(vars["locale"])
+	span.SetTag("product-id", productId)
+	span.SetTag("locale", locale)
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Delete translation failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("translation-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, translationDeleteAction, translationPolicyAttributes(product, locale)) {
+		span.SetTag("translation-deleted", false)
+		span.LogKV("translation-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete %s translation of product %d", locale, productId)
+
+	deleteErr := database.DeleteTranslation(tx, productId, locale, ctx)
+	if deleteErr != nil {
+		var errMsg string
+		switch deleteErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete translation failed: translation not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete translation failed: " + deleteErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("translation-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("translation-deleted", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("translation-deleted", true)
+	span.LogKV("translation-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
@@ -1,8 +1,10 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -16,42 +18,111 @@ const (
 	rootProductsEndpoint = "/products"
 	productsIdEndpoint   = rootProductsEndpoint + "/{id:[0-9]+}"
 
+	productVariantsEndpoint  = productsIdEndpoint + "/variants"
+	productVariantIdEndpoint = productVariantsEndpoint + "/{variantId:[0-9]+}"
+
+	productTranslationsEndpoint = productsIdEndpoint + "/translations"
+	productTranslationEndpoint  = productTranslationsEndpoint + "/{locale}"
+
+	productImagesEndpoint       = productsIdEndpoint + "/images"
+	productImageIdEndpoint      = productImagesEndpoint + "/{imageId:[0-9]+}"
+	productImageContentEndpoint = productImageIdEndpoint + "/content"
+
+	productReviewsEndpoint          = productsIdEndpoint + "/reviews"
+	productReviewIdEndpoint         = productReviewsEndpoint + "/{reviewId:[0-9]+}"
+	productReviewModerationEndpoint = productReviewIdEndpoint + "/moderation"
+
+	rootPromotionsEndpoint = "/promotions"
+	promotionIdEndpoint    = rootPromotionsEndpoint + "/{id:[0-9]+}"
+
+	rootApiKeysEndpoint = "/apikeys"
+	apiKeyIdEndpoint    = rootApiKeysEndpoint + "/{id:[0-9]+}"
+
+	rootCategoriesEndpoint = "/categories"
+	categorySchemaEndpoint = rootCategoriesEndpoint + "/{category}/schema"
+
 	contentTypeHeaderKey       = "Content-Type"
 	contentTypeApplicationJson = "application/json"
+	contentTypeProblemJson     = "application/problem+json"
 )
 
 // SERVER
 
 func (s *Server) setupRouter() {
-	logging.Log.Debug("Create new router")
+	logging.Named(logging.RestLogger).Debug("Create new router")
 
 	s.router = mux.NewRouter().StrictSlash(true)
 
-	s.router.Use(requestInfoPrintingMiddleware)
-
-	s.router.HandleFunc(rootProductsEndpoint, s.getProducts).Methods(http.MethodGet)
-	s.router.HandleFunc(productsIdEndpoint, s.getProduct).Methods(http.MethodGet)
-	s.router.HandleFunc(rootProductsEndpoint, s.createProduct).Methods(http.MethodPost)
-	s.router.HandleFunc(productsIdEndpoint, s.updateProduct).Methods(http.MethodPut)
-	s.router.HandleFunc(productsIdEndpoint, s.deleteProduct).Methods(http.MethodDelete)
+	// the deadline also bounds the queries of authentication, e.g. the lookup of API keys
+	s.router.Use(s.deadlineMiddleware)
+	s.router.Use(s.authenticationMiddleware)
+	s.router.Use(s.apiKeyAuthenticationMiddleware)
+	s.router.Use(s.rateLimitMiddleware)
+	s.router.Use(s.scopeMiddleware)
+	s.router.Use(s.tenancyMiddleware)
+
+	s.router.Handle(rootProductsEndpoint, requireScope(productsReadScope, s.getProducts)).Methods(http.MethodGet)
+	s.router.Handle(productsIdEndpoint, requireScope(productsReadScope, s.getProduct)).Methods(http.MethodGet)
+	s.router.Handle(rootProductsEndpoint, requireScope(productsWriteScope, s.createProduct)).Methods(http.MethodPost)
+	s.router.Handle(productsIdEndpoint, requireScope(productsWriteScope, s.updateProduct)).Methods(http.MethodPut)
+	s.router.Handle(productsIdEndpoint, requireScope(productsWriteScope, s.deleteProduct)).Methods(http.MethodDelete)
+
+	s.router.Handle(productVariantsEndpoint, requireScope(productsReadScope, s.getVariants)).Methods(http.MethodGet)
+	s.router.Handle(productVariantIdEndpoint, requireScope(productsReadScope, s.getVariant)).Methods(http.MethodGet)
+	s.router.Handle(productVariantsEndpoint, requireScope(productsWriteScope, s.createVariant)).Methods(http.MethodPost)
+	s.router.Handle(productVariantIdEndpoint, requireScope(productsWriteScope, s.updateVariant)).Methods(http.MethodPut)
+	s.router.Handle(productVariantIdEndpoint, requireScope(productsWriteScope, s.deleteVariant)).Methods(http.MethodDelete)
+
+	s.router.Handle(productTranslationsEndpoint, requireScope(productsReadScope, s.getTranslations)).Methods(http.MethodGet)
+	s.router.Handle(productTranslationEndpoint, requireScope(productsWriteScope, s.putTranslation)).Methods(http.MethodPut)
+	s.router.Handle(productTranslationEndpoint, requireScope(productsWriteScope, s.deleteTranslation)).Methods(http.MethodDelete)
+
+	s.router.Handle(productImagesEndpoint, requireScope(productsReadScope, s.getImages)).Methods(http.MethodGet)
+	s.router.Handle(productImageContentEndpoint, requireScope(productsReadScope, s.getImageContent)).Methods(http.MethodGet)
+	s.router.Handle(productImagesEndpoint, requireScope(productsWriteScope, s.uploadImage)).Methods(http.MethodPost)
+	s.router.Handle(productImageIdEndpoint, requireScope(productsWriteScope, s.updateImage)).Methods(http.MethodPut)
+	s.router.Handle(productImageIdEndpoint, requireScope(productsWriteScope, s.deleteImage)).Methods(http.MethodDelete)
+
+	s.router.Handle(productReviewsEndpoint, requireScope(productsReadScope, s.getReviews)).Methods(http.MethodGet)
+	s.router.Handle(productReviewsEndpoint, requireScope(productsReadScope, s.createReview)).Methods(http.MethodPost)
+	s.router.Handle(productReviewModerationEndpoint, requireScope(productsAdminScope, s.moderateReview)).Methods(http.MethodPut)
+	s.router.Handle(productReviewIdEndpoint, requireScope(productsAdminScope, s.deleteReview)).Methods(http.MethodDelete)
+
+	s.router.Handle(rootPromotionsEndpoint, requireScope(productsReadScope, s.getPromotions)).Methods(http.MethodGet)
+	s.router.Handle(promotionIdEndpoint, requireScope(productsReadScope, s.getPromotion)).Methods(http.MethodGet)
+	s.router.Handle(rootPromotionsEndpoint, requireScope(productsAdminScope, s.createPromotion)).Methods(http.MethodPost)
+	s.router.Handle(promotionIdEndpoint, requireScope(productsAdminScope, s.updatePromotion)).Methods(http.MethodPut)
+	s.router.Handle(promotionIdEndpoint, requireScope(productsAdminScope, s.deletePromotion)).Methods(http.MethodDelete)
+
+	s.router.Handle(rootApiKeysEndpoint, requireScope(productsAdminScope, s.getApiKeys)).Methods(http.MethodGet)
+	s.router.Handle(rootApiKeysEndpoint, requireScope(productsAdminScope, s.createApiKey)).Methods(http.MethodPost)
+	s.router.Handle(apiKeyIdEndpoint, requireScope(productsAdminScope, s.revokeApiKey)).Methods(http.MethodDelete)
+
+	s.router.Handle(rootCategoriesEndpoint, requireScope(productsReadScope, s.getCategorySchemas)).Methods(http.MethodGet)
+	s.router.Handle(categorySchemaEndpoint, requireScope(productsReadScope, s.getCategorySchema)).Methods(http.MethodGet)
+	s.router.Handle(categorySchemaEndpoint, requireScope(productsAdminScope, s.putCategorySchema)).Methods(http.MethodPut)
+	s.router.Handle(categorySchemaEndpoint, requireScope(productsAdminScope, s.deleteCategorySchema)).Methods(http.MethodDelete)
 }
 
 func (s *Server) setupHTTPServer() {
-	logging.SugaredLog.Debugf("Create new HTTP server on port %d", s.config.restPort)
+	logging.Named(logging.RestLogger).Debugf("Create new HTTP server on port %d", s.config.restPort)
 
 	if s.config != nil {
 		s.httpServer = &http.Server{
 			Addr:    fmt.Sprintf(commons.HttpServerHostFormat, s.config.restHost, s.config.restPort),
-			Handler: s.router,
+			Handler: requestIdMiddleware(s.accessLogMiddleware(s.metricsMiddleware(s.tracingMiddleware(s.recoveryMiddleware(s.router))))), // outside the router to also log unmatched requests
 			// Good practice to set timeouts to avoid Slowloris attacks.
 			WriteTimeout: commons.HttpServerWriteTimeoutDefault,
 			ReadTimeout:  commons.HttpServerReadTimeoutDefault,
 			IdleTimeout:  commons.HttpServerIdelTimeoutDefault,
+			BaseContext: func(net.Listener) context.Context {
+				return s.baseCtx
+			},
 		}
 		return
 	}
 
-	logging.Log.Error("HTTP server creation failed: REST server configurations not loaded")
+	logging.Named(logging.RestLogger).Error("HTTP server creation failed: REST server configurations not loaded")
 }
 
 // HANDLERS
@@ -62,10 +133,28 @@ func sendJsonResponse(writer http.ResponseWriter, code int, payload interface{})
 	writer.WriteHeader(code)
 	_, err := writer.Write(response)
 	if err != nil {
-		logging.SugaredLog.Errorf("Error sending JSON response: %s", err.Error())
+		logging.Named(logging.RestLogger).Errorf("Error sending JSON response: %s", err.Error())
 	}
 }
 
 func sendErrorResponse(writer http.ResponseWriter, code int, message string) {
 	sendJsonResponse(writer, code, map[string]string{"error": message})
 }
+
+// sendProblemResponse answers with an RFC 7807 problem, used for authentication and authorization failures.
+func sendProblemResponse(writer http.ResponseWriter, code int, detail string) {
+	response, _ := json.Marshal(&problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+		// set by requestIdMiddleware before any handler writes
+		RequestId: writer.Header().Get(requestIdHeaderKey),
+	})
+	writer.Header().Set(contentTypeHeaderKey, contentTypeProblemJson)
+	writer.WriteHeader(code)
+	_, err := writer.Write(response)
+	if err != nil {
+		logging.Named(logging.RestLogger).Errorf("Error sending problem response: %s", err.Error())
+	}
+}
@@ -0,0 +1,409 @@
+package rest
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/bygui86/go-postgres-cicd/commons"
+	"github.com/bygui86/go-postgres-cicd/database"
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func (s *Server) getVariants(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-variants-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get variants failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get variants of product %d", productId)
+	span.SetTag("product-id", productId)
+
+	product := &database.Product{ID: productId}
+	getErr := database.GetProduct(tx, product, ctx)
+	if getErr == nil && !isProductVisible(request, product) {
+		getErr = sql.ErrNoRows
+	}
+	var variants []*database.Variant
+	if getErr == nil {
+		variants, getErr = database.GetVariants(tx, productId, ctx)
+	}
+	if getErr != nil {
+		var errMsg string
+		switch getErr {
+		case sql.ErrNoRows:
+			errMsg = "Get variants failed: product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get variants failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variants-found", 0)
+		span.SetTag("error", errMsg)
+		span.LogKV("variants-found", 0, "error", errMsg)
+		return
+	}
+
+	span.SetTag("variants-found", len(variants))
+	span.LogKV("variants-found", len(variants))
+
+	sendJsonResponse(writer, http.StatusOK, variants)
+}
+
+func (s *Server) getVariant(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "get-variant-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Get variant failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, variantId := variantIdsFromRequest(request)
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Get variant %d of product %d", variantId, productId)
+	span.SetTag("product-id", productId)
+	span.SetTag("variant-id", variantId)
+
+	product := &database.Product{ID: productId}
+	getErr := database.GetProduct(tx, product, ctx)
+	if getErr == nil && !isProductVisible(request, product) {
+		getErr = sql.ErrNoRows
+	}
+	variant := &database.Variant{ID: variantId, ProductID: productId}
+	if getErr == nil {
+		getErr = database.GetVariant(tx, variant, ctx)
+	}
+	if getErr != nil {
+		var errMsg string
+		switch getErr {
+		case sql.ErrNoRows:
+			errMsg = "Get variant failed: variant not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Get variant failed: " + getErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-found", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-found", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("variant-found", true)
+	span.LogKV("variant-found", true)
+
+	sendJsonResponse(writer, http.StatusOK, variant)
+}
+
+func (s *Server) createVariant(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "create-variant-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Create variant failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, _ := strconv.Atoi(mux.Vars(request)["id"])
+	span.SetTag("product-id", productId)
+
+	var variant *database.Variant
+	unmarshErr := json.NewDecoder(request.Body).Decode(&variant)
+	if unmarshErr != nil || variant == nil {
+		errMsg := "Create variant failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("variant-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-created", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	variant.ProductID = productId
+	validateErr := variant.Validate()
+	if validateErr != nil {
+		errMsg := "Create variant failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("variant-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-created", false, "error", errMsg)
+		return
+	}
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Create variant failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("variant-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-created", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, variantCreateAction, variantPolicyAttributes(product, variant)) {
+		span.SetTag("variant-created", false)
+		span.LogKV("variant-created", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Create variant %s", variant.String())
+
+	createErr := database.CreateVariant(tx, variant, ctx)
+	if createErr != nil {
+		errMsg := "Create variant failed: "
+		switch {
+		case database.IsForeignKeyViolation(createErr):
+			errMsg += "product not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		case database.IsUniqueViolation(createErr):
+			errMsg += "sku already exists"
+			sendErrorResponse(writer, http.StatusConflict, errMsg)
+		default:
+			errMsg += createErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-created", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-created", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("variant", variant.String())
+	span.SetTag("variant-created", true)
+	span.LogKV("variant", variant.String(), "variant-created", true)
+
+	sendJsonResponse(writer, http.StatusCreated, variant)
+}
+
+func (s *Server) updateVariant(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "update-variant-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Update variant failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, variantId := variantIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("variant-id", variantId)
+
+	var variant *database.Variant
+	unmarshErr := json.NewDecoder(request.Body).Decode(&variant)
+	if unmarshErr != nil || variant == nil {
+		errMsg := "Update variant failed: invalid request payload"
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("variant-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-updated", false, "error", errMsg)
+		return
+	}
+	defer request.Body.Close()
+
+	variant.ID = variantId
+	variant.ProductID = productId
+	validateErr := variant.Validate()
+	if validateErr != nil {
+		errMsg := "Update variant failed: " + validateErr.Error()
+		sendErrorResponse(writer, http.StatusBadRequest, errMsg)
+
+		span.SetTag("variant-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-updated", false, "error", errMsg)
+		return
+	}
+
+	// policy conditions may compare the variant with its current version, e.g. to bound price changes
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Update variant failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("variant-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-updated", false, "error", errMsg)
+		return
+	}
+	// the current version is locked so that concurrent updates are checked in turn
+	current := &database.Variant{ID: variantId, ProductID: productId}
+	currentErr := database.GetVariantForUpdate(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Update variant failed: variant not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Update variant failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-updated", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, variantUpdateAction, variantUpdatePolicyAttributes(product, current, variant)) {
+		span.SetTag("variant-updated", false)
+		span.LogKV("variant-updated", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Update variant %s", variant.String())
+
+	updateErr := database.UpdateVariant(tx, variant, ctx)
+	if updateErr != nil {
+		errMsg := "Update variant failed: "
+		switch {
+		case updateErr == sql.ErrNoRows:
+			errMsg += "variant not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		case database.IsUniqueViolation(updateErr):
+			errMsg += "sku already exists"
+			sendErrorResponse(writer, http.StatusConflict, errMsg)
+		default:
+			errMsg += updateErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-updated", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-updated", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("variant", variant.String())
+	span.SetTag("variant-updated", true)
+	span.LogKV("variant", variant.String(), "variant-updated", true)
+
+	sendJsonResponse(writer, http.StatusOK, variant)
+}
+
+func (s *Server) deleteVariant(writer http.ResponseWriter, request *http.Request) {
+	span, ctx := retrieveSpanAndCtx(request, "delete-variant-handler")
+	defer span.Finish()
+
+	span.SetTag("app", commons.ServiceName)
+
+	tx, txErr := tenantTx(request)
+	if txErr != nil {
+		errMsg := "Delete variant failed: " + txErr.Error()
+		sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+
+		span.SetTag("error", errMsg)
+		span.LogKV("error", errMsg)
+		return
+	}
+
+	productId, variantId := variantIdsFromRequest(request)
+	span.SetTag("product-id", productId)
+	span.SetTag("variant-id", variantId)
+
+	product, productCode, productErr := policyTargetProduct(tx, productId, ctx)
+	if productErr != nil {
+		errMsg := "Delete variant failed: " + productErr.Error()
+		sendErrorResponse(writer, productCode, errMsg)
+
+		span.SetTag("variant-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-deleted", false, "error", errMsg)
+		return
+	}
+	current := &database.Variant{ID: variantId, ProductID: productId}
+	currentErr := database.GetVariant(tx, current, ctx)
+	if currentErr != nil {
+		var errMsg string
+		switch currentErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete variant failed: variant not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete variant failed: " + currentErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-deleted", false, "error", errMsg)
+		return
+	}
+	if !s.authorize(writer, request, span, variantDeleteAction, variantPolicyAttributes(product, current)) {
+		span.SetTag("variant-deleted", false)
+		span.LogKV("variant-deleted", false)
+		return
+	}
+
+	logging.NamedFromContext(logging.RestLogger, ctx).Infof("Delete variant %d of product %d", variantId, productId)
+
+	deleteErr := database.DeleteVariant(tx, productId, variantId, ctx)
+	if deleteErr != nil {
+		var errMsg string
+		switch deleteErr {
+		case sql.ErrNoRows:
+			errMsg = "Delete variant failed: variant not found"
+			sendErrorResponse(writer, http.StatusNotFound, errMsg)
+		default:
+			errMsg = "Delete variant failed: " + deleteErr.Error()
+			sendErrorResponse(writer, http.StatusInternalServerError, errMsg)
+		}
+
+		span.SetTag("variant-deleted", false)
+		span.SetTag("error", errMsg)
+		span.LogKV("variant-deleted", false, "error", errMsg)
+		return
+	}
+
+	span.SetTag("variant-deleted", true)
+	span.LogKV("variant-deleted", true)
+
+	sendJsonResponse(writer, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// variantIdsFromRequest returns the product and variant IDs, already validated as numbers by the route patterns.
+func variantIdsFromRequest(request *http.Request) (int, int) {
+	vars := mux.Vars(request)
+	productId, _ := strconv.Atoi(vars["id"])
+	variantId, _ := strconv.Atoi(vars["variantId"])
+	return productId, variantId
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore persists binary assets under opaque keys, e.g. "products/42/3f2a...".
+// Implementations must be safe for concurrent use.
+type BlobStore interface {
+	// Put stores the content under the key, replacing any previous content
+	Put(ctx context.Context, key string, content io.Reader) error
+	// Get returns the content stored under the key, or ErrBlobNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the content stored under the key, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+func New() (BlobStore, error) {
+	cfg := loadConfig()
+
+	switch cfg.backend {
+	case BackendFilesystem:
+		return NewFilesystemBlobStore(cfg.filesystemRoot)
+	default:
+		return nil, errors.New("blob store backend " + cfg.backend + " not supported")
+	}
+}
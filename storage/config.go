@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+const (
+	BackendFilesystem = "filesystem"
+
+	backendEnvVar        = "BLOBSTORE_BACKEND" // available values: filesystem
+	filesystemRootEnvVar = "BLOBSTORE_FS_ROOT"
+
+	backendDefault        = BackendFilesystem
+	filesystemRootDefault = "./data/blobs"
+)
+
+func loadConfig() *config {
+	logging.Log.Debug("Load blob store configurations")
+	return &config{
+		backend:        utils.GetStringEnv(backendEnvVar, backendDefault),
+		filesystemRoot: utils.GetStringEnv(filesystemRootEnvVar, filesystemRootDefault),
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+const (
+	dirPermissions = 0750
+	tmpFilePattern = ".upload-*"
+)
+
+func NewFilesystemBlobStore(root string) (*FilesystemBlobStore, error) {
+	logging.SugaredLog.Infof("Create new filesystem blob store in %s", root)
+
+	absRoot, absErr := filepath.Abs(root)
+	if absErr != nil {
+		return nil, absErr
+	}
+	mkdirErr := os.MkdirAll(absRoot, dirPermissions)
+	if mkdirErr != nil {
+		return nil, mkdirErr
+	}
+	return &FilesystemBlobStore{root: absRoot}, nil
+}
+
+// Put writes to a temporary file renamed once complete, so readers never see partial content.
+func (f *FilesystemBlobStore) Put(ctx context.Context, key string, content io.Reader) error {
+	path, pathErr := f.path(key)
+	if pathErr != nil {
+		return pathErr
+	}
+	dir := filepath.Dir(path)
+	mkdirErr := os.MkdirAll(dir, dirPermissions)
+	if mkdirErr != nil {
+		return mkdirErr
+	}
+
+	tmpFile, tmpErr := ioutil.TempFile(dir, tmpFilePattern)
+	if tmpErr != nil {
+		return tmpErr
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, copyErr := io.Copy(tmpFile, &contextReader{ctx: ctx, reader: content})
+	closeErr := tmpFile.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
+
+func (f *FilesystemBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, pathErr := f.path(key)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+	file, openErr := os.Open(path)
+	if os.IsNotExist(openErr) {
+		return nil, ErrBlobNotFound
+	}
+	if openErr != nil {
+		return nil, openErr
+	}
+	return file, nil
+}
+
+func (f *FilesystemBlobStore) Delete(ctx context.Context, key string) error {
+	path, pathErr := f.path(key)
+	if pathErr != nil {
+		return pathErr
+	}
+	removeErr := os.Remove(path)
+	if removeErr != nil && !os.IsNotExist(removeErr) {
+		return removeErr
+	}
+	return nil
+}
+
+// path maps the key to a file under the root, rejecting keys that would escape it.
+func (f *FilesystemBlobStore) path(key string) (string, error) {
+	path := filepath.Join(f.root, filepath.FromSlash(key))
+	if key == "" || !strings.HasPrefix(path, f.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("blob key %q not valid", key)
+	}
+	return path, nil
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(p)
+}
@@ -0,0 +1,76 @@
+// +build !integration
+
+package storage_test
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+	"github.com/bygui86/go-postgres-cicd/storage"
+)
+
+const (
+	blobKey     = "products/42/image"
+	blobContent = "not really an image"
+)
+
+func TestFilesystemBlobStore_PutGetDelete(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	store, storeErr := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, storeErr)
+	ctx := context.Background()
+
+	putErr := store.Put(ctx, blobKey, strings.NewReader(blobContent))
+	require.NoError(t, putErr)
+
+	reader, getErr := store.Get(ctx, blobKey)
+	require.NoError(t, getErr)
+	content, readErr := ioutil.ReadAll(reader)
+	require.NoError(t, readErr)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, blobContent, string(content))
+
+	deleteErr := store.Delete(ctx, blobKey)
+	assert.NoError(t, deleteErr)
+
+	_, missingErr := store.Get(ctx, blobKey)
+	assert.Equal(t, storage.ErrBlobNotFound, missingErr)
+
+	deleteAgainErr := store.Delete(ctx, blobKey)
+	assert.NoError(t, deleteAgainErr)
+}
+
+func TestFilesystemBlobStore_Fail_KeyOutsideRoot(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	store, storeErr := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, storeErr)
+
+	putErr := store.Put(context.Background(), "../escape", strings.NewReader(blobContent))
+	assert.Error(t, putErr)
+}
+
+func TestFilesystemBlobStore_Fail_ContextCancelled(t *testing.T) {
+	logErr := logging.InitGlobalLogger()
+	require.NoError(t, logErr)
+
+	store, storeErr := storage.NewFilesystemBlobStore(t.TempDir())
+	require.NoError(t, storeErr)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	putErr := store.Put(ctx, blobKey, strings.NewReader(blobContent))
+	assert.Error(t, putErr)
+
+	_, getErr := store.Get(context.Background(), blobKey)
+	assert.Equal(t, storage.ErrBlobNotFound, getErr)
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+type config struct {
+	backend        string
+	filesystemRoot string
+}
+
+type FilesystemBlobStore struct {
+	root string
+}
+
+// contextReader stops a copy as soon as the context is done, e.g. when the client disconnects.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
@@ -0,0 +1,19 @@
+package tracing
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// traceIdExemplarLabel follows the OpenMetrics convention, understood by Grafana to link exemplars to traces
+const traceIdExemplarLabel = "trace_id"
+
+// ObserveWithTraceID observes the value, attaching the trace ID as exemplar if any, e.g. not with the no-op tracer nor
+// for unsampled traces, see SampledTraceID.
+func ObserveWithTraceID(observer prometheus.Observer, value float64, traceId string) {
+	exemplarObserver, ok := observer.(prometheus.ExemplarObserver)
+	if traceId == "" || !ok {
+		observer.Observe(value)
+		return
+	}
+	exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{traceIdExemplarLabel: traceId})
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"github.com/opentracing/opentracing-go"
+	zipkinopentracing "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/uber/jaeger-client-go"
+
+	"github.com/bygui86/go-postgres-cicd/logging"
+)
+
+func init() {
+	logging.SetSpanIdsFunc(func(spanContext opentracing.SpanContext) (string, string) {
+		return TraceID(spanContext), SpanID(spanContext)
+	})
+}
+
+// TraceID returns the trace ID of a Jaeger or Zipkin span context, empty for any other tracer, e.g. the no-op one.
+func TraceID(spanContext opentracing.SpanContext) string {
+	switch typed := spanContext.(type) {
+	case jaeger.SpanContext:
+		if typed.IsValid() {
+			return typed.TraceID().String()
+		}
+	case zipkinopentracing.SpanContext:
+		if !typed.TraceID.Empty() {
+			return typed.TraceID.String()
+		}
+	}
+	return ""
+}
+
+// SpanID returns the span ID of a Jaeger or Zipkin span context, empty for any other tracer.
+func SpanID(spanContext opentracing.SpanContext) string {
+	switch typed := spanContext.(type) {
+	case jaeger.SpanContext:
+		if typed.IsValid() {
+			return typed.SpanID().String()
+		}
+	case zipkinopentracing.SpanContext:
+		if !typed.TraceID.Empty() {
+			return typed.ID.String()
+		}
+	}
+	return ""
+}
+
+// SampledTraceID returns the trace ID of a Jaeger or Zipkin span context only if the trace is sampled, i.e. reported
+// to the tracing backend, empty otherwise: exemplars must not link to traces that cannot be found.
+func SampledTraceID(spanContext opentracing.SpanContext) string {
+	switch typed := spanContext.(type) {
+	case jaeger.SpanContext:
+		if typed.IsValid() && typed.IsSampled() {
+			return typed.TraceID().String()
+		}
+	case zipkinopentracing.SpanContext:
+		if !typed.TraceID.Empty() && (typed.Debug || (typed.Sampled != nil && *typed.Sampled)) {
+			return typed.TraceID.String()
+		}
+	}
+	return ""
+}
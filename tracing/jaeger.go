@@ -57,7 +57,7 @@ func InitSampleJaeger(serviceName string) (io.Closer, error) {
 		return nil, tracerErr
 	}
 
-	logging.SugaredLog.Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return closer, nil
 }
 
@@ -78,14 +78,14 @@ func InitTestingJaeger(serviceName string) (io.Closer, error) {
 	// Initialize tracing with a logger and a metrics factory
 	closer, tracerErr := cfg.InitGlobalTracer(
 		serviceName,
-		jaegercfg.Logger(jaegerlogzap.NewLogger(logging.Log)),
+		jaegercfg.Logger(&jaegerHealthLogger{jaegerlogzap.NewLogger(logging.Named(logging.TracingLogger).Desugar())}),
 		jaegercfg.Metrics(jaegerprom.New(jaegerprom.WithRegisterer(prometheus.DefaultRegisterer))),
 	)
 	if tracerErr != nil {
 		return nil, tracerErr
 	}
 
-	logging.SugaredLog.Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return closer, nil
 }
 
@@ -96,13 +96,13 @@ func InitProductionJaeger(serviceName string) (io.Closer, error) {
 	// Initialize tracing with a logger and a metrics factory
 	closer, tracerErr := cfg.InitGlobalTracer(
 		serviceName,
-		jaegercfg.Logger(jaegerlogzap.NewLogger(logging.Log)),
+		jaegercfg.Logger(&jaegerHealthLogger{jaegerlogzap.NewLogger(logging.Named(logging.TracingLogger).Desugar())}),
 		jaegercfg.Metrics(jaegerprom.New(jaegerprom.WithRegisterer(prometheus.DefaultRegisterer))),
 	)
 	if tracerErr != nil {
 		return nil, tracerErr
 	}
 
-	logging.SugaredLog.Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Jaeger global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return closer, nil
 }
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jaegerlogzap "github.com/uber/jaeger-client-go/log/zap"
+)
+
+// reporterFailureWindow is how long a failure to send spans keeps the reporter unhealthy when no success follows,
+// the Jaeger reporter telling nothing about its successes
+const reporterFailureWindow = time.Minute
+
+// reporterHealth records the outcome of the attempts of the global tracer to send its spans.
+type reporterHealth struct {
+	mutex       sync.Mutex
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     string
+}
+
+var reporterStatus = &reporterHealth{}
+
+// CheckReporter fails if the span reporter of the global tracer failed to send spans recently.
+func CheckReporter(ctx context.Context) error {
+	return reporterStatus.check(time.Now())
+}
+
+func (h *reporterHealth) succeeded() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastSuccess = time.Now()
+}
+
+func (h *reporterHealth) failed(err string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastFailure = time.Now()
+	h.lastErr = err
+}
+
+func (h *reporterHealth) check(now time.Time) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.lastFailure.After(h.lastSuccess) && now.Sub(h.lastFailure) < reporterFailureWindow {
+		return fmt.Errorf("span reporter failing since %s: %s", h.lastFailure.Format(time.RFC3339), h.lastErr)
+	}
+	return nil
+}
+
+// jaegerHealthLogger is the Jaeger logger, it also records the errors of the reporter, the only way it tells them.
+type jaegerHealthLogger struct {
+	*jaegerlogzap.Logger
+}
+
+func (l *jaegerHealthLogger) Error(msg string) {
+	// e.g. "error reporting Jaeger span" or "failed to flush Jaeger spans to server"
+	if strings.Contains(msg, "Jaeger span") {
+		reporterStatus.failed(msg)
+	}
+	l.Logger.Error(msg)
+}
+
+// zipkinHealthClient is the HTTP client of the Zipkin reporter, it records the outcome of each batch of spans sent.
+type zipkinHealthClient struct {
+	client *http.Client
+}
+
+func (c *zipkinHealthClient) Do(request *http.Request) (*http.Response, error) {
+	response, err := c.client.Do(request)
+	switch {
+	case err != nil:
+		reporterStatus.failed(err.Error())
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		reporterStatus.failed(fmt.Sprintf("collector answered %s", response.Status))
+	default:
+		reporterStatus.succeeded()
+	}
+	return response, err
+}
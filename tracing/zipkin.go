@@ -2,6 +2,7 @@ package tracing
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/opentracing/opentracing-go"
 	zipkinopentracing "github.com/openzipkin-contrib/zipkin-go-opentracing"
@@ -19,7 +20,8 @@ const (
 
 func InitSampleZipkin(serviceName, zipkinHost string, zipkinPort int) (reporter.Reporter, error) {
 	// set up a span reporter
-	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort))
+	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort),
+		zipkinhttpreporter.Client(&zipkinHealthClient{client: &http.Client{}}))
 
 	// create our local service endpoint
 	endpoint, endpointErr := zipkin.NewEndpoint(serviceName, zipkinDefaultHostPort)
@@ -40,13 +42,14 @@ func InitSampleZipkin(serviceName, zipkinHost string, zipkinPort int) (reporter.
 	// and set it as Global OpenTracing tracer instance
 	opentracing.SetGlobalTracer(zipkinopentracing.Wrap(nativeTracer))
 
-	logging.SugaredLog.Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return reporter, nil
 }
 
 func InitTestingZipkin(serviceName, zipkinHost string, zipkinPort int) (reporter.Reporter, error) {
 	// set up a span reporter
-	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort))
+	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort),
+		zipkinhttpreporter.Client(&zipkinHealthClient{client: &http.Client{}}))
 
 	// create our local service endpoint
 	endpoint, endpointErr := zipkin.NewEndpoint(serviceName, zipkinDefaultHostPort)
@@ -74,13 +77,14 @@ func InitTestingZipkin(serviceName, zipkinHost string, zipkinPort int) (reporter
 	// and set it as Global OpenTracing tracer instance
 	opentracing.SetGlobalTracer(zipkinopentracing.Wrap(nativeTracer))
 
-	logging.SugaredLog.Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return reporter, nil
 }
 
 func InitProductionZipkin(serviceName, zipkinHost string, zipkinPort int) (reporter.Reporter, error) {
 	// set up a span reporter
-	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort))
+	reporter := zipkinhttpreporter.NewReporter(fmt.Sprintf(zipkinUrlFormat, zipkinHost, zipkinPort),
+		zipkinhttpreporter.Client(&zipkinHealthClient{client: &http.Client{}}))
 
 	// create our local service endpoint
 	endpoint, endpointErr := zipkin.NewEndpoint(serviceName, zipkinDefaultHostPort)
@@ -108,6 +112,6 @@ func InitProductionZipkin(serviceName, zipkinHost string, zipkinPort int) (repor
 	// and set it as Global OpenTracing tracer instance
 	opentracing.SetGlobalTracer(zipkinopentracing.Wrap(nativeTracer))
 
-	logging.SugaredLog.Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
+	logging.Named(logging.TracingLogger).Debugf("Zipkin global tracer registered: %t", opentracing.IsGlobalTracerRegistered())
 	return reporter, nil
 }
@@ -3,6 +3,8 @@ package utils
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func GetStringEnv(key, fallback string) string {
@@ -33,3 +35,40 @@ func GetBoolEnv(key string, fallback bool) bool {
 	}
 	return fallback
 }
+
+func GetDurationEnv(key string, fallback time.Duration) time.Duration {
+	if strValue, ok := os.LookupEnv(key); ok {
+		value, err := time.ParseDuration(strValue)
+		if err != nil {
+			return fallback
+		}
+		return value
+	}
+	return fallback
+}
+
+func GetFloatEnv(key string, fallback float64) float64 {
+	if strValue, ok := os.LookupEnv(key); ok {
+		value, err := strconv.ParseFloat(strValue, 64)
+		if err != nil {
+			return fallback
+		}
+		return value
+	}
+	return fallback
+}
+
+// GetStringSliceEnv splits a comma separated value, trimming spaces and dropping empty items.
+func GetStringSliceEnv(key string, fallback []string) []string {
+	if strValue, ok := os.LookupEnv(key); ok {
+		values := make([]string, 0)
+		for _, value := range strings.Split(strValue, ",") {
+			value = strings.TrimSpace(value)
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	return fallback
+}
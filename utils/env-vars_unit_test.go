@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,6 +26,21 @@ const (
 	boolKey      = "DB_EXAMPLE_BOOL"
 	boolValue    = true
 	boolFallback = false
+
+	durationKey      = "DB_EXAMPLE_DURATION"
+	durationValue    = 30 * time.Second
+	durationFallback = time.Minute
+
+	floatKey      = "DB_EXAMPLE_FLOAT"
+	floatValue    = 0.25
+	floatFallback = 1.0
+
+	sliceKey = "DB_EXAMPLE_SLICE"
+)
+
+var (
+	sliceValue    = []string{"/metrics", "/health"}
+	sliceFallback = []string{"fallback"}
 )
 
 func TestGetStringEnv_Success(t *testing.T) {
@@ -109,3 +125,89 @@ func TestGetBoolEnv_Fallback_Format(t *testing.T) {
 	unsetErr := os.Unsetenv(boolKey)
 	require.NoError(t, unsetErr)
 }
+
+func TestGetDurationEnv_Success(t *testing.T) {
+	setErr := os.Setenv(durationKey, durationValue.String())
+	require.NoError(t, setErr)
+
+	value := utils.GetDurationEnv(durationKey, durationFallback)
+
+	assert.Equal(t, durationValue, value)
+
+	unsetErr := os.Unsetenv(durationKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetDurationEnv_Fallback_NotSet(t *testing.T) {
+	value := utils.GetDurationEnv(durationKey, durationFallback)
+
+	assert.NotEqual(t, durationValue, value)
+	assert.Equal(t, durationFallback, value)
+}
+
+func TestGetDurationEnv_Fallback_Format(t *testing.T) {
+	setErr := os.Setenv(durationKey, "30 seconds")
+	require.NoError(t, setErr)
+
+	value := utils.GetDurationEnv(durationKey, durationFallback)
+
+	assert.NotEqual(t, durationValue, value)
+	assert.Equal(t, durationFallback, value)
+
+	unsetErr := os.Unsetenv(durationKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetFloatEnv_Success(t *testing.T) {
+	setErr := os.Setenv(floatKey, "0.25")
+	require.NoError(t, setErr)
+
+	value := utils.GetFloatEnv(floatKey, floatFallback)
+
+	assert.Equal(t, floatValue, value)
+
+	unsetErr := os.Unsetenv(floatKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetFloatEnv_Fallback_Format(t *testing.T) {
+	setErr := os.Setenv(floatKey, "quarter")
+	require.NoError(t, setErr)
+
+	value := utils.GetFloatEnv(floatKey, floatFallback)
+
+	assert.Equal(t, floatFallback, value)
+
+	unsetErr := os.Unsetenv(floatKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetStringSliceEnv_Success(t *testing.T) {
+	setErr := os.Setenv(sliceKey, " /metrics,, /health ")
+	require.NoError(t, setErr)
+
+	value := utils.GetStringSliceEnv(sliceKey, sliceFallback)
+
+	assert.Equal(t, sliceValue, value)
+
+	unsetErr := os.Unsetenv(sliceKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetStringSliceEnv_Empty(t *testing.T) {
+	setErr := os.Setenv(sliceKey, "")
+	require.NoError(t, setErr)
+
+	value := utils.GetStringSliceEnv(sliceKey, sliceFallback)
+
+	assert.Empty(t, value)
+
+	unsetErr := os.Unsetenv(sliceKey)
+	require.NoError(t, unsetErr)
+}
+
+func TestGetStringSliceEnv_Fallback_NotSet(t *testing.T) {
+	value := utils.GetStringSliceEnv(sliceKey, sliceFallback)
+
+	assert.Equal(t, sliceFallback, value)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	routesSeparator = ";"
+	routeSeparator  = "="
+)
+
+// ParseRouteValues parses per route settings like "GET /products=10/s;DELETE /products/{id}=1/m" into their values
+// keyed by "METHOD /path", the method in upper case. Values are left to the caller to parse.
+func ParseRouteValues(routeValues string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, routeValue := range strings.Split(routeValues, routesSeparator) {
+		routeValue = strings.TrimSpace(routeValue)
+		if routeValue == "" {
+			continue
+		}
+		parts := strings.SplitN(routeValue, routeSeparator, 2)
+		fields := strings.Fields(parts[0])
+		if len(parts) != 2 || len(fields) != 2 {
+			return nil, fmt.Errorf("route setting %q not valid, expected METHOD /path=value", routeValue)
+		}
+		values[strings.ToUpper(fields[0])+" "+fields[1]] = strings.TrimSpace(parts[1])
+	}
+	return values, nil
+}
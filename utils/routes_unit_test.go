@@ -0,0 +1,36 @@
+// +build !integration
+
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bygui86/go-postgres-cicd/utils"
+)
+
+func TestParseRouteValues_Success(t *testing.T) {
+	values, err := utils.ParseRouteValues(" get  /products = 10/s:20;;DELETE /products/{id}=1/m ")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"GET /products":         "10/s:20",
+		"DELETE /products/{id}": "1/m",
+	}, values)
+}
+
+func TestParseRouteValues_Empty(t *testing.T) {
+	values, err := utils.ParseRouteValues("")
+
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestParseRouteValues_Fail(t *testing.T) {
+	for _, routeValues := range []string{"/products=10/s", "GET /products", "GET /products /variants=1s"} {
+		_, err := utils.ParseRouteValues(routeValues)
+		assert.Error(t, err, routeValues)
+	}
+}